@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version actuelle du format de data/alerts.json.
+const alertsSchemaVersion = 1
+
+// Fichier des alertes de prix, dans le dossier de données.
+const alertsFile = "alerts.json"
+
+// Dossier où le notificateur mail dépose les messages, en guise de boîte d'envoi locale.
+const mailOutboxDir = "outbox"
+
+// Délai maximal d'un appel de webhook.
+const webhookTimeout = 10 * time.Second
+
+var alertsMu sync.Mutex
+
+// Types d'alertes reconnus.
+var alertKinds = []struct{ Key, Label string }{
+	{"below", "Passe sous le seuil"},
+	{"above", "Dépasse le seuil"},
+	{"move", "Varie de plus de N %"},
+}
+
+// PriceAlert est une alerte définie par l'utilisateur sur le prix d'une carte.
+// Pour below et above, Threshold est un prix dans Currency ; pour move, un pourcentage
+// de variation entre deux relevés consécutifs.
+type PriceAlert struct {
+	ID            string    `json:"id"`
+	CardID        string    `json:"cardId"`
+	Kind          string    `json:"kind"`
+	Threshold     float64   `json:"threshold"`
+	Currency      string    `json:"currency"`
+	Variant       string    `json:"variant"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Triggered     bool      `json:"triggered,omitempty"`
+	LastSnapshot  string    `json:"lastSnapshot,omitempty"`
+	LastTriggered time.Time `json:"lastTriggered,omitempty"`
+	// Pending est la dernière notification de l'alerte pas encore délivrée, renvoyée au
+	// prochain relevé tant que le notificateur échoue.
+	Pending *Notification `json:"pending,omitempty"`
+}
+
+// Describe renvoie une description lisible de l'alerte.
+func (a PriceAlert) Describe() string {
+	switch a.Kind {
+	case "below":
+		return "sous " + formatMoney(a.Threshold, a.Currency)
+	case "above":
+		return "au-dessus de " + formatMoney(a.Threshold, a.Currency)
+	case "move":
+		return "variation de plus de " + strconv.FormatFloat(a.Threshold, 'f', -1, 64) + " %"
+	}
+	return a.Kind
+}
+
+// Alerts est le contenu de data/alerts.json.
+type Alerts struct {
+	Version int          `json:"version"`
+	Alerts  []PriceAlert `json:"alerts"`
+}
+
+func newAlerts() Alerts {
+	return Alerts{Version: alertsSchemaVersion, Alerts: []PriceAlert{}}
+}
+
+// ForCard renvoie les alertes d'une carte.
+func (a Alerts) ForCard(cardID string) []PriceAlert {
+	var alerts []PriceAlert
+	for _, alert := range a.Alerts {
+		if alert.CardID == cardID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (a *Alerts) remove(id string) bool {
+	for i := range a.Alerts {
+		if a.Alerts[i].ID == id {
+			a.Alerts = append(a.Alerts[:i], a.Alerts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func loadAlerts() (Alerts, error) {
+	alerts := newAlerts()
+
+	data, err := os.ReadFile(dataPath(alertsFile))
+	if os.IsNotExist(err) {
+		return alerts, nil
+	}
+	if err != nil {
+		return alerts, err
+	}
+
+	if len(data) == 0 {
+		return alerts, nil
+	}
+
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return newAlerts(), fmt.Errorf("fichier d'alertes invalide: %w", err)
+	}
+	if alerts.Alerts == nil {
+		alerts.Alerts = []PriceAlert{}
+	}
+
+	return alerts, nil
+}
+
+func saveAlerts(alerts Alerts) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	alerts.Version = alertsSchemaVersion
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(alertsFile), data, 0644)
+}
+
+// updateAlerts charge les alertes, applique la modification puis les enregistre, sous verrou.
+func updateAlerts(apply func(*Alerts) error) error {
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+
+	alerts, err := loadAlerts()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(&alerts); err != nil {
+		return err
+	}
+
+	return saveAlerts(alerts)
+}
+
+// Notification est le message envoyé lorsqu'une alerte se déclenche.
+type Notification struct {
+	AlertID  string    `json:"alertId"`
+	CardID   string    `json:"cardId"`
+	Message  string    `json:"message"`
+	Price    float64   `json:"price"`
+	Currency string    `json:"currency"`
+	At       time.Time `json:"at"`
+}
+
+// Notifier délivre les notifications d'alertes.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// logNotifier écrit les notifications dans le journal.
+type logNotifier struct{}
+
+func (logNotifier) Name() string { return "log" }
+
+func (logNotifier) Notify(ctx context.Context, n Notification) error {
+	slog.InfoContext(ctx, "Alerte de prix", "alert", n.AlertID, "message", n.Message)
+	return nil
+}
+
+// webhookNotifier envoie les notifications en JSON par POST à une URL.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n webhookNotifier) Name() string { return "webhook" }
+
+func (n webhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: code de statut %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// mailNotifier tient lieu d'envoi de mails : chaque notification est déposée
+// sous forme de message .eml dans une boîte d'envoi locale.
+type mailNotifier struct {
+	dir string
+	to  string
+}
+
+func (n mailNotifier) Name() string { return "mail" }
+
+func (n mailNotifier) Notify(ctx context.Context, notification Notification) error {
+	if err := os.MkdirAll(n.dir, 0755); err != nil {
+		return err
+	}
+
+	message := "To: " + n.to + "\r\n" +
+		"From: poketracker@localhost\r\n" +
+		"Date: " + notification.At.Format(time.RFC1123Z) + "\r\n" +
+		"Subject: Alerte de prix " + notification.CardID + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		notification.Message + "\r\n"
+
+	name := notification.At.Format("20060102-150405") + "-" + notification.AlertID + ".eml"
+	return writeFileAtomic(filepath.Join(n.dir, name), []byte(message), 0644)
+}
+
+// multiNotifier délivre chaque notification à plusieurs notificateurs.
+type multiNotifier []Notifier
+
+func (m multiNotifier) Name() string {
+	names := make([]string, len(m))
+	for i, n := range m {
+		names[i] = n.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (m multiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []string
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, notifier.Name()+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// alertNotifier est le notificateur utilisé par la tâche de relevé des prix.
+var alertNotifier Notifier = logNotifier{}
+
+// notifierFromConfig construit le notificateur à partir des notificateurs configurés
+// (parmi log, webhook et mail).
+func notifierFromConfig(cfg Config) Notifier {
+	var notifiers multiNotifier
+	for _, name := range cfg.Notifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, logNotifier{})
+		case "webhook":
+			notifiers = append(notifiers, webhookNotifier{url: cfg.WebhookURL, client: &http.Client{Timeout: webhookTimeout}})
+		case "mail":
+			notifiers = append(notifiers, mailNotifier{dir: dataPath(mailOutboxDir), to: cfg.MailTo})
+		default:
+			slog.Warn("Notificateur inconnu ignoré", "notifier", name)
+		}
+	}
+
+	if len(notifiers) == 0 {
+		return logNotifier{}
+	}
+	if len(notifiers) == 1 {
+		return notifiers[0]
+	}
+	return notifiers
+}
+
+// latestPrices renvoie le dernier relevé de prix de la finition et celui qui le précède.
+func latestPrices(history []PriceSnapshot, variant, currency string) (string, float64, float64) {
+	date, current, previous := "", 0.0, 0.0
+	for i := len(history) - 1; i >= 0; i-- {
+		price := priceIn(history[i].Quotes, variant, currency)
+		if price <= 0 {
+			continue
+		}
+		if date == "" {
+			date, current = history[i].Date, price
+			continue
+		}
+		previous = price
+		break
+	}
+	return date, current, previous
+}
+
+// evaluateAlerts compare chaque alerte au dernier relevé de sa carte.
+// Une alerte de seuil ne se déclenche qu'au franchissement ; une alerte de variation
+// est évaluée une fois par relevé. Chaque notification est d'abord enregistrée comme en
+// attente avec l'alerte, puis envoyée hors du verrou, et n'est retirée qu'une fois délivrée :
+// une notification dont l'envoi échoue est renvoyée au relevé suivant.
+func evaluateAlerts(ctx context.Context, prices Prices, notifier Notifier) error {
+	var notifications []Notification
+
+	err := updateAlerts(func(a *Alerts) error {
+		for i := range a.Alerts {
+			alert := &a.Alerts[i]
+			date, current, previous := latestPrices(prices.History(alert.CardID), alert.Variant, alert.Currency)
+			if date == "" || date == alert.LastSnapshot {
+				if alert.Pending != nil {
+					notifications = append(notifications, *alert.Pending)
+				}
+				continue
+			}
+			alert.LastSnapshot = date
+
+			message := ""
+			switch alert.Kind {
+			case "below", "above":
+				crossed := current < alert.Threshold
+				if alert.Kind == "above" {
+					crossed = current > alert.Threshold
+				}
+				if crossed && !alert.Triggered {
+					message = fmt.Sprintf("%s est %s : %s", alert.CardID, alert.Describe(), formatMoney(current, alert.Currency))
+				}
+				alert.Triggered = crossed
+			case "move":
+				if previous <= 0 {
+					break
+				}
+				change := (current - previous) / previous * 100
+				if math.Abs(change) >= alert.Threshold {
+					message = fmt.Sprintf("%s a varié de %+.1f %% : %s → %s", alert.CardID, change,
+						formatMoney(previous, alert.Currency), formatMoney(current, alert.Currency))
+				}
+			}
+
+			if message != "" {
+				alert.LastTriggered = time.Now()
+				// Une notification plus récente remplace celle qui n'a pas pu être délivrée.
+				alert.Pending = &Notification{
+					AlertID:  alert.ID,
+					CardID:   alert.CardID,
+					Message:  message,
+					Price:    current,
+					Currency: alert.Currency,
+					At:       alert.LastTriggered,
+				}
+			}
+			if alert.Pending != nil {
+				notifications = append(notifications, *alert.Pending)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var delivered []Notification
+	for _, n := range notifications {
+		if err := notifier.Notify(ctx, n); err != nil {
+			slog.ErrorContext(ctx, "Impossible de notifier l'alerte, nouvel essai au prochain relevé", "alert", n.AlertID, "notifier", notifier.Name(), "err", err)
+			continue
+		}
+		delivered = append(delivered, n)
+	}
+	if len(delivered) == 0 {
+		return nil
+	}
+
+	return updateAlerts(func(a *Alerts) error {
+		for _, n := range delivered {
+			for i := range a.Alerts {
+				// L'alerte a pu être supprimée ou redéclenchée pendant l'envoi.
+				if pending := a.Alerts[i].Pending; a.Alerts[i].ID == n.AlertID && pending != nil && pending.At.Equal(n.At) {
+					a.Alerts[i].Pending = nil
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// parseAlert lit une alerte depuis un formulaire.
+func parseAlert(r *http.Request) (PriceAlert, error) {
+	alert := PriceAlert{
+		CardID:    strings.TrimSpace(r.FormValue("cardId")),
+		Kind:      r.FormValue("kind"),
+		Currency:  requestCurrency(r),
+		Variant:   r.FormValue("variant"),
+		CreatedAt: time.Now(),
+	}
+	if alert.CardID == "" {
+		return alert, fmt.Errorf("carte non spécifiée")
+	}
+
+	valid := false
+	for _, kind := range alertKinds {
+		if kind.Key == alert.Kind {
+			valid = true
+		}
+	}
+	if !valid {
+		return alert, fmt.Errorf("type d'alerte invalide: %s", alert.Kind)
+	}
+
+	threshold, err := strconv.ParseFloat(strings.Replace(r.FormValue("threshold"), ",", ".", 1), 64)
+	if err != nil || threshold <= 0 {
+		return alert, fmt.Errorf("seuil invalide: %s", r.FormValue("threshold"))
+	}
+	alert.Threshold = threshold
+
+	if alert.Variant == "" {
+		alert.Variant = "normal"
+	}
+	return alert, nil
+}
+
+// alertsHandler gère /alerts (liste), /alerts/add, /alerts/{id}/delete et /alerts/run (POST).
+func alertsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/alerts"), "/")
+	if rest == "" {
+		alertsPageHandler(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		return
+	}
+
+	redirect := localRedirect(r.FormValue("back"), "/alerts")
+
+	parts := strings.Split(rest, "/")
+	var err error
+	switch {
+	case len(parts) == 1 && parts[0] == "add":
+		var alert PriceAlert
+		alert, err = parseAlert(r)
+		if err == nil {
+			alert.ID = newOwnedID()
+			err = updateAlerts(func(a *Alerts) error {
+				a.Alerts = append(a.Alerts, alert)
+				return nil
+			})
+		}
+
+	case len(parts) == 1 && parts[0] == "run":
+		_, err = snapshotPrices(ctx)
+
+	case len(parts) == 2 && parts[1] == "delete":
+		err = updateAlerts(func(a *Alerts) error {
+			if !a.remove(parts[0]) {
+				return fmt.Errorf("alerte introuvable: %s", parts[0])
+			}
+			return nil
+		})
+
+	default:
+		showError(w, "Page non trouvée", fmt.Errorf("URL invalide: %s", r.URL.Path))
+		return
+	}
+
+	if err != nil {
+		showError(w, "Impossible de mettre à jour les alertes", err)
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+func alertsPageHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := loadAlerts()
+	if err != nil {
+		showError(w, "Impossible de charger les alertes", err)
+		return
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Alertes de prix</h2>
+            <p class="results-count">` + strconv.Itoa(len(alerts.Alerts)) + ` alerte(s) — notification : ` + escape(alertNotifier.Name()) + `</p>
+        </div>`
+
+	if len(alerts.Alerts) == 0 {
+		html += `
+        <div class="no-results">
+            <p>Aucune alerte. Créez-en depuis l'historique des prix d'une carte.</p>
+        </div>`
+	} else {
+		html += renderAlertTable(alerts.Alerts, "/alerts", true)
+	}
+
+	html += `
+        <form action="/alerts/run" method="POST" class="list-form">
+            <button type="submit" class="button secondary">Relever les prix maintenant</button>
+        </form>`
+
+	writePage(w, "Alertes de prix", html, "")
+}
+
+func renderAlertTable(alerts []PriceAlert, back string, showCard bool) string {
+	html := `
+            <table class="deck-table">
+                <tr>`
+	if showCard {
+		html += `<th>Carte</th>`
+	}
+	html += `<th>Condition</th><th>Finition</th><th>Dernier déclenchement</th><th></th></tr>`
+
+	for _, alert := range alerts {
+		html += `
+                <tr>`
+		if showCard {
+			html += `<td><a href="/card/` + escape(alert.CardID) + `/prices">` + escape(alert.CardID) + `</a></td>`
+		}
+		last := "—"
+		if !alert.LastTriggered.IsZero() {
+			last = alert.LastTriggered.Format("02/01/2006 15:04")
+		}
+		if alert.Pending != nil {
+			last += ` <span class="pending-badge">notification en attente</span>`
+		}
+		html += `<td>` + escape(alert.Describe()) + `</td><td>` + escape(alert.Variant) + `</td><td>` + last + `</td>
+                    <td>
+                        <form action="/alerts/` + escape(alert.ID) + `/delete" method="POST">
+                            <input type="hidden" name="back" value="` + escape(back) + `">
+                            <button type="submit" class="button danger">Supprimer</button>
+                        </form>
+                    </td>
+                </tr>`
+	}
+
+	return html + `
+            </table>`
+}
+
+func renderAlertForm(card Card, currency string) string {
+	kinds := ""
+	for _, kind := range alertKinds {
+		kinds += `<option value="` + kind.Key + `">` + kind.Label + `</option>`
+	}
+	variants := ""
+	for _, v := range cardVariants {
+		variants += `<option value="` + v.Key + `"` + selected(v.Key == defaultVariant(card)) + `>` + v.Label + `</option>`
+	}
+	currencies := ""
+	for _, c := range priceCurrencies {
+		currencies += `<option value="` + c + `"` + selected(c == currency) + `>` + c + `</option>`
+	}
+
+	return `
+        <form action="/alerts/add" method="POST" class="list-form">
+            <h3>Nouvelle alerte</h3>
+            <input type="hidden" name="cardId" value="` + escape(card.ID) + `">
+            <input type="hidden" name="back" value="/card/` + escape(card.ID) + `/prices">
+            <select name="kind">` + kinds + `</select>
+            <input type="text" name="threshold" placeholder="Seuil (prix ou %)" required>
+            <select name="currency">` + currencies + `</select>
+            <select name="variant">` + variants + `</select>
+            <button type="submit" class="button">Créer l'alerte</button>
+        </form>`
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Taille de la main de départ, et nombre de cartes vues par défaut (main + première pioche).
+const (
+	openingHandSize   = 7
+	defaultDrawSample = 8
+)
+
+// Couleurs des types d'énergie, alignées sur les variables --type-* de la feuille de style.
+var energyTypeColors = map[string]string{
+	"Colorless": "#A8A878",
+	"Darkness":  "#705848",
+	"Dragon":    "#7038F8",
+	"Fairy":     "#F0B6BC",
+	"Fighting":  "#C03028",
+	"Fire":      "#F08030",
+	"Grass":     "#78C850",
+	"Lightning": "#F8D030",
+	"Metal":     "#B8B8D0",
+	"Psychic":   "#F85888",
+	"Water":     "#6890F0",
+}
+
+// Tranches de l'histogramme du coût en énergie des attaques ; la dernière regroupe les coûts supérieurs.
+var attackCostBuckets = []string{"0", "1", "2", "3", "4", "5+"}
+
+// Une ligne de liste collée : « 4 sv03-125 », « sv03-125 x4 » ou simplement « sv03-125 ».
+var countedIDRe = regexp.MustCompile(`^(?:(\d+)\s*x?\s+)?([A-Za-z0-9.]+-[A-Za-z0-9.]+)(?:\s+x?(\d+))?$`)
+
+// DeckStats regroupe les statistiques calculées sur une liste de cartes avec quantités.
+type DeckStats struct {
+	Total       int
+	Basics      int
+	Categories  map[string]int
+	Types       map[string]int
+	AttackCosts []int
+	Unresolved  int
+}
+
+// parseCountedIDs lit une liste collée d'IDs de cartes avec quantités ; les doublons sont cumulés.
+// Les lignes non reconnues sont renvoyées à part.
+func parseCountedIDs(text string) ([]DeckEntry, []string) {
+	var entries []DeckEntry
+	var invalid []string
+	index := make(map[string]int)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := countedIDRe.FindStringSubmatch(line)
+		if m == nil || (m[1] != "" && m[3] != "") {
+			invalid = append(invalid, line)
+			continue
+		}
+
+		count := 1
+		for _, raw := range []string{m[1], m[3]} {
+			if raw != "" {
+				count, _ = strconv.Atoi(raw)
+			}
+		}
+		if count < 1 {
+			invalid = append(invalid, line)
+			continue
+		}
+
+		if i, ok := index[m[2]]; ok {
+			entries[i].Count += count
+			continue
+		}
+		index[m[2]] = len(entries)
+		entries = append(entries, DeckEntry{CardID: m[2], Count: count})
+	}
+
+	return entries, invalid
+}
+
+// computeDeckStats calcule la répartition des catégories, des types et du coût des attaques.
+// Chaque carte est pondérée par sa quantité.
+func computeDeckStats(entries []DeckEntry, cards map[string]HydratedCard) DeckStats {
+	stats := DeckStats{
+		Categories:  make(map[string]int),
+		Types:       make(map[string]int),
+		AttackCosts: make([]int, len(attackCostBuckets)),
+	}
+
+	for _, entry := range entries {
+		stats.Total += entry.Count
+
+		h := cards[entry.CardID]
+		if h.Unknown || h.Err != nil {
+			stats.Unresolved += entry.Count
+			continue
+		}
+
+		card := h.Card
+		stats.Categories[card.Category] += entry.Count
+		if isBasicPokemon(card) {
+			stats.Basics += entry.Count
+		}
+		if card.Category != "Pokemon" {
+			continue
+		}
+
+		for _, t := range card.Types {
+			stats.Types[t] += entry.Count
+		}
+		for _, attack := range card.Attacks {
+			bucket := len(attack.Cost)
+			if bucket >= len(attackCostBuckets) {
+				bucket = len(attackCostBuckets) - 1
+			}
+			stats.AttackCosts[bucket] += entry.Count
+		}
+	}
+
+	return stats
+}
+
+// probNone renvoie la probabilité hypergéométrique de ne piocher aucune des copies
+// parmi draws cartes tirées d'un paquet de size cartes qui en contient copies.
+func probNone(size, copies, draws int) float64 {
+	if copies <= 0 || size <= 0 {
+		return 1
+	}
+	if draws > size {
+		draws = size
+	}
+	if draws > size-copies {
+		return 0
+	}
+
+	p := 1.0
+	for i := 0; i < draws; i++ {
+		p *= float64(size-copies-i) / float64(size-i)
+	}
+	return p
+}
+
+// probAtLeastOne renvoie la probabilité de piocher au moins une des copies parmi draws cartes.
+func probAtLeastOne(size, copies, draws int) float64 {
+	return 1 - probNone(size, copies, draws)
+}
+
+// mulliganRate renvoie la probabilité qu'une main de départ ne contienne aucun Pokémon de base.
+func mulliganRate(size, basics int) float64 {
+	if basics <= 0 {
+		return 1
+	}
+	return probNone(size, basics, openingHandSize)
+}
+
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p*100, 'f', 1, 64) + " %"
+}
+
+// analyticsSource détermine les cartes à analyser : liste (?list=), deck (?deck=) ou liste collée (cards).
+// Dans une liste, chaque carte compte pour un exemplaire.
+func analyticsSource(r *http.Request) (string, []DeckEntry, []string, error) {
+	if text := r.FormValue("cards"); strings.TrimSpace(text) != "" {
+		entries, invalid := parseCountedIDs(text)
+		return "Liste collée", entries, invalid, nil
+	}
+
+	if slug := r.FormValue("deck"); slug != "" {
+		decks, err := loadDecks()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		deck := decks.Deck(slug)
+		if deck == nil {
+			return "", nil, nil, fmt.Errorf("aucun deck %s", slug)
+		}
+		return "Deck « " + deck.Name + " »", append([]DeckEntry(nil), deck.Cards...), nil, nil
+	}
+
+	slug := r.FormValue("list")
+	if slug == "" {
+		slug = favoritesListSlug
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	list := favorites.List(slug)
+	if list == nil {
+		return "", nil, nil, errListNotFound
+	}
+
+	entries := make([]DeckEntry, 0, len(list.Cards))
+	for _, id := range list.IDs() {
+		entries = append(entries, DeckEntry{CardID: id, Count: 1})
+	}
+	return "Liste « " + list.Name + " »", entries, nil, nil
+}
+
+// analyticsHandler affiche les statistiques d'une liste : répartition, types, coût des attaques
+// et probabilités de pioche.
+func analyticsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	label, entries, invalid, err := analyticsSource(r)
+	if err != nil {
+		showError(w, "Impossible de charger les cartes à analyser", err)
+		return
+	}
+
+	draws := defaultDrawSample
+	if n, err := strconv.Atoi(r.FormValue("n")); err == nil && n > 0 && n <= maxDeckCopies {
+		draws = n
+	}
+
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.CardID
+	}
+	cards := make(map[string]HydratedCard)
+	for _, h := range hydrateCards(ctx, ids) {
+		cards[h.ID] = h
+	}
+	stats := computeDeckStats(entries, cards)
+
+	html := `
+        <div class="page-header">
+            <h2>Statistiques</h2>
+            <p class="results-count">` + escape(label) + ` — ` + strconv.Itoa(stats.Total) + ` cartes</p>
+        </div>`
+
+	if len(invalid) > 0 {
+		html += `
+        <div class="error-message">
+            <p>Lignes ignorées :</p>
+            <ul>`
+		for _, line := range invalid {
+			html += `
+                <li>` + escape(line) + `</li>`
+		}
+		html += `
+            </ul>
+        </div>`
+	}
+	if stats.Unresolved > 0 {
+		html += `
+        <p class="unknown-card">` + strconv.Itoa(stats.Unresolved) + ` carte(s) introuvable(s) ou indisponible(s), exclue(s) des répartitions.</p>`
+	}
+
+	if stats.Total == 0 {
+		html += `
+        <div class="no-results">
+            <p>Aucune carte à analyser.</p>
+        </div>`
+	} else {
+		html += renderDeckCharts(stats) + renderDrawOdds(entries, cards, stats, draws)
+	}
+
+	html += renderAnalyticsForm(r.FormValue("cards"), draws)
+
+	writePage(w, "Statistiques", html, "")
+}
+
+func renderDeckCharts(stats DeckStats) string {
+	var split []ChartPoint
+	for _, category := range deckCategories {
+		split = append(split, ChartPoint{Label: category.Label, Value: float64(stats.Categories[category.Key])})
+	}
+
+	typeNames := make([]string, 0, len(stats.Types))
+	for t := range stats.Types {
+		typeNames = append(typeNames, t)
+	}
+	sort.Slice(typeNames, func(i, j int) bool {
+		if stats.Types[typeNames[i]] != stats.Types[typeNames[j]] {
+			return stats.Types[typeNames[i]] > stats.Types[typeNames[j]]
+		}
+		return typeNames[i] < typeNames[j]
+	})
+	var types []ChartPoint
+	for _, t := range typeNames {
+		types = append(types, ChartPoint{Label: t, Value: float64(stats.Types[t]), Color: energyTypeColors[t]})
+	}
+
+	var costs []ChartPoint
+	for i, bucket := range attackCostBuckets {
+		costs = append(costs, ChartPoint{Label: bucket, Value: float64(stats.AttackCosts[i])})
+	}
+
+	return `
+        <section class="analytics-section">
+            <h3>Répartition</h3>
+            <div class="analytics-charts">` +
+		barChartSVG("Pokémon / Dresseur / Énergie", split) +
+		barChartSVG("Types des Pokémon", types) +
+		barChartSVG("Coût en énergie des attaques", costs) + `
+            </div>
+        </section>`
+}
+
+func renderDrawOdds(entries []DeckEntry, cards map[string]HydratedCard, stats DeckStats, draws int) string {
+	mulligan := mulliganRate(stats.Total, stats.Basics)
+
+	html := `
+        <section class="analytics-section">
+            <h3>Main de départ</h3>
+            <ul class="analytics-odds">
+                <li>Pokémon de base : <strong>` + strconv.Itoa(stats.Basics) + `</strong></li>
+                <li>Chance d'ouvrir avec un Pokémon de base : <strong>` + formatPercent(1-mulligan) + `</strong></li>
+                <li>Taux de mulligan : <strong>` + formatPercent(mulligan) + `</strong></li>`
+	if mulligan < 1 {
+		html += `
+                <li>Mulligans attendus par partie : <strong>` + strconv.FormatFloat(mulligan/(1-mulligan), 'f', 2, 64) + `</strong></li>`
+	}
+	html += `
+            </ul>
+        </section>`
+
+	sorted := append([]DeckEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return cards[sorted[i].CardID].Card.Name < cards[sorted[j].CardID].Card.Name
+	})
+
+	html += `
+        <section class="analytics-section">
+            <h3>Probabilité de piocher au moins un exemplaire</h3>
+            <table class="deck-table analytics-table">
+                <tr>
+                    <th>Carte</th>
+                    <th>Copies</th>
+                    <th>Main de départ (` + strconv.Itoa(openingHandSize) + `)</th>
+                    <th>` + strconv.Itoa(draws) + ` premières cartes</th>
+                </tr>`
+	for _, entry := range sorted {
+		name := cards[entry.CardID].Card.Name
+		if name == "" {
+			name = entry.CardID
+		}
+		html += `
+                <tr>
+                    <td><a href="/card/` + escape(entry.CardID) + `">` + escape(name) + `</a></td>
+                    <td>` + strconv.Itoa(entry.Count) + `</td>
+                    <td>` + formatPercent(probAtLeastOne(stats.Total, entry.Count, openingHandSize)) + `</td>
+                    <td>` + formatPercent(probAtLeastOne(stats.Total, entry.Count, draws)) + `</td>
+                </tr>`
+	}
+	html += `
+            </table>`
+
+	// Courbe de probabilité selon le nombre de cartes vues, pour chaque quantité présente dans la liste.
+	seen := make(map[int]bool)
+	var series []ChartSeries
+	for _, entry := range sorted {
+		if seen[entry.Count] {
+			continue
+		}
+		seen[entry.Count] = true
+
+		s := ChartSeries{Name: strconv.Itoa(entry.Count) + " copie(s)"}
+		limit := stats.Total
+		if limit > 20 {
+			limit = 20
+		}
+		for n := 1; n <= limit; n++ {
+			s.Points = append(s.Points, ChartXY{X: float64(n), Y: probAtLeastOne(stats.Total, entry.Count, n) * 100})
+		}
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+
+	html += lineChartSVG("Probabilité (%) selon le nombre de cartes vues", series, func(x float64) string {
+		return strconv.Itoa(int(x))
+	}) + `
+        </section>`
+
+	return html
+}
+
+func renderAnalyticsForm(cards string, draws int) string {
+	return `
+        <form action="/analytics" method="POST" class="list-form import-form">
+            <h3>Analyser une liste collée</h3>
+            <textarea name="cards" rows="8" placeholder="4 sv03-125&#10;2 sv01-4&#10;sv03-1 x3">` + escape(cards) + `</textarea>
+            <label>Cartes vues <input type="number" name="n" min="1" max="` + strconv.Itoa(maxDeckCopies) + `" value="` + strconv.Itoa(draws) + `"></label>
+            <button type="submit" class="button">Analyser</button>
+        </form>`
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Artist regroupe les cartes d'un illustrateur, toutes graphies confondues.
+type Artist struct {
+	Key      string
+	Name     string
+	Variants []NameCount
+	Cards    []IndexedCard
+}
+
+// artistKey normalise un nom d'illustrateur : casse, accents courants, ponctuation et espaces
+// sont ignorés pour que les variantes d'orthographe se regroupent. Contrairement à slugify,
+// les lettres hors ASCII (japonais, cyrillique...) sont conservées, pour qu'un nom qui n'en
+// contient pas d'autres garde une clé.
+func artistKey(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range accentReplacer.Replace(strings.ToLower(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// artistURL renvoie l'adresse de la page d'un illustrateur.
+func artistURL(name string) string {
+	return "/artist/" + url.PathEscape(artistKey(name))
+}
+
+// cardIllustrator renvoie l'illustrateur d'une carte, quel que soit le champ renseigné par l'API.
+func cardIllustrator(card Card) string {
+	if card.Illustrator != "" {
+		return card.Illustrator
+	}
+	return card.Artist
+}
+
+// groupArtists regroupe les cartes de l'index par illustrateur normalisé.
+// Le nom affiché est la graphie la plus fréquente.
+func groupArtists(index CatalogueIndex) map[string]*Artist {
+	artists := make(map[string]*Artist)
+	spellings := make(map[string]map[string]int)
+
+	for _, card := range index.Cards {
+		name := strings.Join(strings.Fields(card.Illustrator), " ")
+		key := artistKey(name)
+		if key == "" {
+			continue
+		}
+		if artists[key] == nil {
+			artists[key] = &Artist{Key: key}
+			spellings[key] = make(map[string]int)
+		}
+		artists[key].Cards = append(artists[key].Cards, card)
+		spellings[key][name]++
+	}
+
+	for key, artist := range artists {
+		artist.Variants = sortedCounts(spellings[key])
+		artist.Name = artist.Variants[0].Name
+	}
+	return artists
+}
+
+// renderIndexNotBuilt affiche le message commun aux pages qui dépendent de l'index du catalogue.
+func renderIndexNotBuilt(w http.ResponseWriter, title string) {
+	refreshing, progress, _ := catalogueIndexStatus()
+	message := `L'index du catalogue n'a pas encore été construit.`
+	if refreshing {
+		message = `L'index du catalogue est en cours de construction (` + escape(progress) + `).`
+	}
+	writePage(w, title, `
+        <div class="page-header">
+            <h2>`+escape(title)+`</h2>
+            <p class="results-count">`+message+` <a href="/stats">Voir l'état de l'index</a></p>
+        </div>`, "")
+}
+
+// artistsHandler gère /artists : index des illustrateurs (?sort=count|name, ?q= pour filtrer).
+func artistsHandler(w http.ResponseWriter, r *http.Request) {
+	index := currentCatalogueIndex()
+	if index.UpdatedAt.IsZero() && len(index.Cards) == 0 {
+		renderIndexNotBuilt(w, "Illustrateurs")
+		return
+	}
+
+	query := strings.TrimSpace(r.FormValue("q"))
+	sortBy := r.FormValue("sort")
+
+	var artists []*Artist
+	for _, artist := range groupArtists(index) {
+		if query != "" && !strings.Contains(artist.Key, artistKey(query)) {
+			continue
+		}
+		artists = append(artists, artist)
+	}
+	sort.Slice(artists, func(i, j int) bool {
+		if sortBy == "name" || len(artists[i].Cards) == len(artists[j].Cards) {
+			return strings.ToLower(artists[i].Name) < strings.ToLower(artists[j].Name)
+		}
+		return len(artists[i].Cards) > len(artists[j].Cards)
+	})
+
+	html := `
+        <div class="page-header">
+            <h2>Illustrateurs</h2>
+            <p class="results-count">` + strconv.Itoa(len(artists)) + ` illustrateurs</p>
+        </div>
+
+        <form action="/artists" method="GET" class="list-form">
+            <input type="text" name="q" value="` + escape(query) + `" placeholder="Filtrer par nom">
+            <select name="sort">
+                <option value="count"` + selected(sortBy != "name") + `>Par nombre de cartes</option>
+                <option value="name"` + selected(sortBy == "name") + `>Par nom</option>
+            </select>
+            <button type="submit" class="button">Afficher</button>
+        </form>
+
+        <ul class="artist-index">`
+	for _, artist := range artists {
+		html += `
+            <li><a href="` + artistURL(artist.Name) + `">` + escape(artist.Name) + `</a> <span class="results-count">` + strconv.Itoa(len(artist.Cards)) + `</span>`
+		if len(artist.Variants) > 1 {
+			html += ` <span class="artist-variants">(` + strconv.Itoa(len(artist.Variants)) + ` graphies)</span>`
+		}
+		html += `</li>`
+	}
+	html += `
+        </ul>`
+
+	writePage(w, "Illustrateurs", html, "")
+}
+
+// artistHandler gère /artist/{nom} : cartes d'un illustrateur, triées par date de sortie
+// (?sort=date|-date|name), avec le décompte par set et les graphies rencontrées.
+func artistHandler(w http.ResponseWriter, r *http.Request) {
+	name, err := url.PathUnescape(strings.Trim(strings.TrimPrefix(r.URL.Path, "/artist/"), "/"))
+	if err != nil || artistKey(name) == "" {
+		showError(w, "Page non trouvée", fmt.Errorf("illustrateur non spécifié"))
+		return
+	}
+
+	index := currentCatalogueIndex()
+	if index.UpdatedAt.IsZero() && len(index.Cards) == 0 {
+		renderIndexNotBuilt(w, "Illustrateur")
+		return
+	}
+
+	artist := groupArtists(index)[artistKey(name)]
+	if artist == nil {
+		showError(w, "Illustrateur introuvable", fmt.Errorf("aucune carte illustrée par %s", name))
+		return
+	}
+
+	sets := indexedSetsByID(index)
+
+	sortBy := r.FormValue("sort")
+	if sortBy == "" {
+		sortBy = "date"
+	}
+	cards := append([]IndexedCard(nil), artist.Cards...)
+	sort.SliceStable(cards, func(i, j int) bool {
+		di, dj := sets[cards[i].SetID].ReleaseDate, sets[cards[j].SetID].ReleaseDate
+		switch sortBy {
+		case "name":
+			return cards[i].Name < cards[j].Name
+		case "-date":
+			if di != dj {
+				return di > dj
+			}
+		default:
+			if di != dj {
+				return di < dj
+			}
+		}
+		if cards[i].SetID != cards[j].SetID {
+			return cards[i].SetID < cards[j].SetID
+		}
+		return localIDLess(cards[i].LocalID, cards[j].LocalID)
+	})
+
+	perSet := make(map[string]int)
+	var setOrder []string
+	for _, card := range cards {
+		if perSet[card.SetID] == 0 {
+			setOrder = append(setOrder, card.SetID)
+		}
+		perSet[card.SetID]++
+	}
+
+	first, last := "", ""
+	for _, card := range cards {
+		if year := sets[card.SetID].Year(); year != "" {
+			if first == "" || year < first {
+				first = year
+			}
+			if year > last {
+				last = year
+			}
+		}
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>` + escape(artist.Name) + `</h2>
+            <p class="results-count">` + strconv.Itoa(len(cards)) + ` cartes dans ` + strconv.Itoa(len(perSet)) + ` sets`
+	if first != "" {
+		html += ` — de ` + first + ` à ` + last
+	}
+	html += `</p>
+        </div>`
+
+	if len(artist.Variants) > 1 {
+		var variants []string
+		for _, v := range artist.Variants {
+			variants = append(variants, escape(v.Name)+` (`+strconv.Itoa(v.Count)+`)`)
+		}
+		html += `
+        <p class="artist-variants">Graphies rencontrées : ` + strings.Join(variants, ", ") + `</p>`
+	}
+
+	html += `
+        <div class="set-filters">`
+	for _, option := range []struct{ Key, Label string }{{"date", "Plus anciennes"}, {"-date", "Plus récentes"}, {"name", "Par nom"}} {
+		if option.Key == sortBy {
+			html += `
+            <span class="button secondary">` + option.Label + `</span>`
+		} else {
+			html += `
+            <a href="` + artistURL(artist.Name) + `?sort=` + url.QueryEscape(option.Key) + `" class="button outline">` + option.Label + `</a>`
+		}
+	}
+	html += `
+        </div>
+
+        <details class="artist-sets">
+            <summary>Cartes par set</summary>
+            <ul>`
+	for _, setID := range setOrder {
+		setName := sets[setID].Name
+		if setName == "" {
+			setName = setID
+		}
+		html += `
+                <li><a href="/set/` + escape(setID) + `">` + escape(setName) + `</a> — ` + strconv.Itoa(perSet[setID]) + `</li>`
+	}
+	html += `
+            </ul>
+        </details>
+
+        <div class="card-grid fade-in">`
+	for _, indexed := range cards {
+		card := indexed.Card()
+		card.Set.Name = sets[indexed.SetID].Name
+		html += renderCardTile(HydratedCard{ID: card.ID, Card: card}, "")
+	}
+	html += `
+        </div>`
+
+	writePage(w, artist.Name, html, "")
+}
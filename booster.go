@@ -0,0 +1,584 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Version actuelle du format de data/boosters.json.
+const boostersSchemaVersion = 1
+
+// Fichier de configuration des boosters, dans le dossier de données.
+const boostersFile = "boosters.json"
+
+// Limites du simulateur, pour garder des temps de réponse raisonnables.
+const (
+	maxPacksPerOpening = 36
+	maxSimulatedBoxes  = 100
+	maxCompletionRuns  = 10
+	maxCompletionPacks = 20000
+)
+
+// Raretés courantes : tout ce qui est tiré au-delà compte comme un « hit ».
+var baseRarities = map[string]bool{
+	"Common":    true,
+	"Uncommon":  true,
+	"Rare":      true,
+	"Rare Holo": true,
+	"None":      true,
+	"":          true,
+}
+
+// BoosterSlot décrit un emplacement du booster : Count cartes tirées selon les poids de rareté.
+// Les raretés absentes du set sont ignorées ; si aucune n'est présente, la carte est tirée
+// parmi toutes les cartes du set.
+type BoosterSlot struct {
+	Name     string             `json:"name"`
+	Count    int                `json:"count"`
+	Rarities map[string]float64 `json:"rarities"`
+}
+
+// BoosterConfig décrit la composition d'un booster et le nombre de boosters par display.
+type BoosterConfig struct {
+	PacksPerBox int           `json:"packsPerBox"`
+	Slots       []BoosterSlot `json:"slots"`
+}
+
+// Boosters est le contenu de data/boosters.json : une configuration par défaut
+// et des configurations propres à certains sets, indexées par ID de set.
+type Boosters struct {
+	Version int                      `json:"version"`
+	Default BoosterConfig            `json:"default"`
+	Sets    map[string]BoosterConfig `json:"sets,omitempty"`
+}
+
+// defaultBoosterConfig reprend grossièrement la composition d'un booster de l'ère Écarlate et Violet.
+var defaultBoosterConfig = BoosterConfig{
+	PacksPerBox: 36,
+	Slots: []BoosterSlot{
+		{Name: "Communes", Count: 4, Rarities: map[string]float64{"Common": 1}},
+		{Name: "Peu communes", Count: 3, Rarities: map[string]float64{"Uncommon": 1}},
+		{Name: "Reverse", Count: 1, Rarities: map[string]float64{"Common": 6, "Uncommon": 3, "Rare": 1}},
+		{Name: "Reverse / illustration", Count: 1, Rarities: map[string]float64{
+			"Common": 55, "Uncommon": 30, "Rare": 6,
+			"Illustration rare": 7.5, "Special illustration rare": 1.2, "Hyper rare": 0.3,
+		}},
+		{Name: "Rare", Count: 1, Rarities: map[string]float64{
+			"Rare": 70, "Rare Holo": 70, "Double rare": 16, "ACE SPEC Rare": 5,
+			"Ultra Rare": 6, "Rare Holo V": 10, "Rare Holo VMAX": 4, "Rare Ultra": 5, "Rare Secret": 1.5, "Rare Rainbow": 1.5,
+		}},
+	},
+}
+
+func newBoosters() Boosters {
+	return Boosters{
+		Version: boostersSchemaVersion,
+		Default: defaultBoosterConfig,
+		Sets:    map[string]BoosterConfig{},
+	}
+}
+
+// ForSet renvoie la configuration du set, ou la configuration par défaut.
+func (b Boosters) ForSet(setID string) (BoosterConfig, bool) {
+	if config, ok := b.Sets[setID]; ok && len(config.Slots) > 0 {
+		if config.PacksPerBox <= 0 {
+			config.PacksPerBox = b.Default.PacksPerBox
+		}
+		return config, true
+	}
+	return b.Default, false
+}
+
+func loadBoosters() (Boosters, error) {
+	boosters := newBoosters()
+
+	data, err := os.ReadFile(dataPath(boostersFile))
+	if os.IsNotExist(err) {
+		return boosters, nil
+	}
+	if err != nil {
+		return boosters, err
+	}
+
+	if len(data) == 0 {
+		return boosters, nil
+	}
+
+	if err := json.Unmarshal(data, &boosters); err != nil {
+		return newBoosters(), fmt.Errorf("fichier de boosters invalide: %w", err)
+	}
+	if len(boosters.Default.Slots) == 0 {
+		boosters.Default = defaultBoosterConfig
+	}
+	if boosters.Default.PacksPerBox <= 0 {
+		boosters.Default.PacksPerBox = defaultBoosterConfig.PacksPerBox
+	}
+
+	return boosters, nil
+}
+
+type slotPicker struct {
+	count      int
+	rarities   []string
+	cumulative []float64
+}
+
+// boosterSim tire des boosters d'un set. Les raretés sont parcourues dans un ordre fixe
+// pour qu'une même graine donne toujours les mêmes tirages.
+type boosterSim struct {
+	all       []Card
+	byRarity  map[string][]Card
+	slots     []slotPicker
+	reachable map[string]bool
+}
+
+func newBoosterSim(cards []Card, config BoosterConfig) *boosterSim {
+	sim := &boosterSim{
+		all:       cards,
+		byRarity:  make(map[string][]Card),
+		reachable: make(map[string]bool),
+	}
+	for _, card := range cards {
+		sim.byRarity[card.Rarity] = append(sim.byRarity[card.Rarity], card)
+	}
+
+	for _, slot := range config.Slots {
+		if slot.Count <= 0 {
+			continue
+		}
+		picker := slotPicker{count: slot.Count}
+
+		rarities := make([]string, 0, len(slot.Rarities))
+		for rarity := range slot.Rarities {
+			rarities = append(rarities, rarity)
+		}
+		sort.Strings(rarities)
+
+		total := 0.0
+		for _, rarity := range rarities {
+			weight := slot.Rarities[rarity]
+			if weight <= 0 || len(sim.byRarity[rarity]) == 0 {
+				continue
+			}
+			total += weight
+			picker.rarities = append(picker.rarities, rarity)
+			picker.cumulative = append(picker.cumulative, total)
+			sim.reachable[rarity] = true
+		}
+		if len(picker.rarities) == 0 {
+			for rarity := range sim.byRarity {
+				sim.reachable[rarity] = true
+			}
+		}
+
+		sim.slots = append(sim.slots, picker)
+	}
+
+	return sim
+}
+
+func (s *boosterSim) openPack(rng *rand.Rand) []Card {
+	var pack []Card
+	for _, slot := range s.slots {
+		for i := 0; i < slot.count; i++ {
+			pool := s.all
+			if len(slot.rarities) > 0 {
+				roll := rng.Float64() * slot.cumulative[len(slot.cumulative)-1]
+				j := sort.SearchFloat64s(slot.cumulative, roll)
+				if j >= len(slot.rarities) {
+					j = len(slot.rarities) - 1
+				}
+				pool = s.byRarity[slot.rarities[j]]
+			}
+			if len(pool) == 0 {
+				continue
+			}
+			pack = append(pack, pool[rng.Intn(len(pool))])
+		}
+	}
+	return pack
+}
+
+// reachableCount renvoie le nombre de cartes du set que la configuration permet de tirer.
+func (s *boosterSim) reachableCount() int {
+	count := 0
+	for rarity, cards := range s.byRarity {
+		if s.reachable[rarity] {
+			count += len(cards)
+		}
+	}
+	return count
+}
+
+// BoxStats agrège les tirages de plusieurs displays simulés.
+type BoxStats struct {
+	Boxes           int
+	Packs           int
+	ByRarity        map[string]int
+	HitsPerBox      []int
+	Unique          int
+	CompletedAtPack int
+	CompletionPacks []int
+	CompletionRuns  int
+}
+
+// simulateBoxes ouvre boxes displays, puis estime le nombre de boosters nécessaires
+// pour obtenir toutes les cartes atteignables du set.
+func simulateBoxes(sim *boosterSim, config BoosterConfig, boxes int, rng *rand.Rand) BoxStats {
+	stats := BoxStats{Boxes: boxes, ByRarity: make(map[string]int)}
+	target := sim.reachableCount()
+	seen := make(map[string]bool)
+
+	for box := 0; box < boxes; box++ {
+		hits := 0
+		for p := 0; p < config.PacksPerBox; p++ {
+			stats.Packs++
+			for _, card := range sim.openPack(rng) {
+				stats.ByRarity[card.Rarity]++
+				if !baseRarities[card.Rarity] {
+					hits++
+				}
+				if !seen[card.ID] {
+					seen[card.ID] = true
+					if len(seen) == target && stats.CompletedAtPack == 0 {
+						stats.CompletedAtPack = stats.Packs
+					}
+				}
+			}
+		}
+		stats.HitsPerBox = append(stats.HitsPerBox, hits)
+	}
+	stats.Unique = len(seen)
+
+	if target == 0 {
+		return stats
+	}
+
+	stats.CompletionRuns = boxes
+	if stats.CompletionRuns > maxCompletionRuns {
+		stats.CompletionRuns = maxCompletionRuns
+	}
+	for run := 0; run < stats.CompletionRuns; run++ {
+		seen := make(map[string]bool)
+		packs := 0
+		for len(seen) < target && packs < maxCompletionPacks {
+			packs++
+			for _, card := range sim.openPack(rng) {
+				seen[card.ID] = true
+			}
+		}
+		if len(seen) < target {
+			packs = -1
+		}
+		stats.CompletionPacks = append(stats.CompletionPacks, packs)
+	}
+
+	return stats
+}
+
+// hydrateSetCards complète les cartes d'un set avec leur rareté, absente de la réponse du set.
+func hydrateSetCards(ctx context.Context, cards []Card) []Card {
+	ids := make([]string, len(cards))
+	for i, card := range cards {
+		ids[i] = card.ID
+	}
+
+	hydrated := make([]Card, len(cards))
+	for i, h := range hydrateCards(ctx, ids) {
+		hydrated[i] = cards[i]
+		if h.Err == nil && !h.Unknown {
+			hydrated[i] = h.Card
+		}
+	}
+	return hydrated
+}
+
+// boosterSeed lit la graine de la requête, ou en tire une nouvelle.
+func boosterSeed(r *http.Request) int64 {
+	if seed, err := strconv.ParseInt(r.FormValue("seed"), 10, 64); err == nil {
+		return seed
+	}
+	return time.Now().UnixNano() % 1000000000
+}
+
+func boundedInt(raw string, fallback, lo, hi int) int {
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+// openPacks ouvre packs boosters avec la graine donnée.
+func openPacks(sim *boosterSim, seed int64, packs int) [][]Card {
+	rng := rand.New(rand.NewSource(seed))
+	opened := make([][]Card, packs)
+	for i := range opened {
+		opened[i] = sim.openPack(rng)
+	}
+	return opened
+}
+
+// boosterHandler gère /set/{id}/open : ouverture de boosters simulés, ajout des cartes tirées
+// à une liste (POST) et statistiques sur plusieurs displays (?boxes=N).
+func boosterHandler(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	set, err := getSet(ctx, id)
+	if err != nil {
+		showError(w, "Impossible de récupérer les détails de la collection", err)
+		return
+	}
+
+	cards, err := fetchSetCards(ctx, id, 0)
+	if err != nil || len(cards) == 0 {
+		showError(w, "Impossible de récupérer les cartes du set", err)
+		return
+	}
+	cards = hydrateSetCards(ctx, cards)
+
+	boosters, err := loadBoosters()
+	if err != nil {
+		slog.ErrorContext(ctx, "Erreur lors du chargement des boosters", "err", err)
+	}
+	config, custom := boosters.ForSet(id)
+	sim := newBoosterSim(cards, config)
+
+	seed := boosterSeed(r)
+	packs := boundedInt(r.FormValue("packs"), 1, 1, maxPacksPerOpening)
+	boxes := boundedInt(r.FormValue("boxes"), 0, 0, maxSimulatedBoxes)
+	opened := openPacks(sim, seed, packs)
+
+	query := url.Values{}
+	query.Set("seed", strconv.FormatInt(seed, 10))
+	query.Set("packs", strconv.Itoa(packs))
+	if boxes > 0 {
+		query.Set("boxes", strconv.Itoa(boxes))
+	}
+
+	if r.Method == http.MethodPost {
+		slug := r.FormValue("list")
+		added := 0
+		err := updateFavorites(func(f *Favorites) error {
+			list := f.List(slug)
+			if list == nil {
+				return errListNotFound
+			}
+			for _, pack := range opened {
+				for _, card := range pack {
+					if list.Add(FavoriteEntry{ID: card.ID, AddedAt: time.Now()}) {
+						added++
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			showError(w, "Impossible d'ajouter les cartes tirées", err)
+			return
+		}
+
+		query.Set("added", strconv.Itoa(added))
+		query.Set("list", slug)
+		http.Redirect(w, r, "/set/"+id+"/open?"+query.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	source := "configuration par défaut"
+	if custom {
+		source = "configuration propre au set (" + dataPath(boostersFile) + ")"
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Ouverture de boosters — ` + escape(set.Name) + `</h2>
+            <p class="results-count">Graine ` + strconv.FormatInt(seed, 10) + ` — ` + strconv.Itoa(packs) + ` booster(s), ` + source + `</p>
+        </div>`
+
+	if added := r.FormValue("added"); added != "" {
+		html += `
+        <p class="success-message">` + escape(added) + ` carte(s) ajoutée(s) à la liste <a href="/lists/` + escape(r.FormValue("list")) + `">` + escape(r.FormValue("list")) + `</a>.</p>`
+	}
+
+	for i, pack := range opened {
+		html += `
+        <section class="booster-pack">
+            <h3>Booster ` + strconv.Itoa(i+1) + `</h3>
+            <div class="card-grid">`
+		for _, card := range pack {
+			html += renderCardTile(HydratedCard{ID: card.ID, Card: card}, `<span class="rarity-badge`+hitClass(card.Rarity)+`">`+escape(rarityLabel(card.Rarity))+`</span>`)
+		}
+		html += `
+            </div>
+        </section>`
+	}
+
+	listOptions := ""
+	if favorites, err := loadFavorites(); err == nil {
+		for _, list := range favorites.Lists {
+			listOptions += `<option value="` + escape(list.Slug) + `">` + escape(list.Name) + `</option>`
+		}
+	}
+
+	html += `
+        <form action="/set/` + id + `/open" method="GET" class="list-form">
+            <h3>Nouvelle ouverture</h3>
+            <label>Boosters <input type="number" name="packs" min="1" max="` + strconv.Itoa(maxPacksPerOpening) + `" value="` + strconv.Itoa(packs) + `"></label>
+            <label>Graine <input type="number" name="seed" placeholder="aléatoire"></label>
+            <label>Displays à simuler <input type="number" name="boxes" min="0" max="` + strconv.Itoa(maxSimulatedBoxes) + `" value="` + strconv.Itoa(boxes) + `"></label>
+            <button type="submit" class="button">Ouvrir</button>
+            <a href="/set/` + id + `/open?` + query.Encode() + `" class="button outline">Lien de cette ouverture</a>
+        </form>
+
+        <form action="/set/` + id + `/open?` + query.Encode() + `" method="POST" class="list-form">
+            <h3>Ajouter les cartes tirées à une liste</h3>
+            <select name="list">` + listOptions + `</select>
+            <button type="submit" class="button secondary">Ajouter</button>
+        </form>`
+
+	if boxes > 0 {
+		stats := simulateBoxes(sim, config, boxes, rand.New(rand.NewSource(seed)))
+		html += renderBoxStats(stats, sim, len(cards))
+	}
+
+	html += renderBoosterConfig(config)
+
+	writePage(w, "Boosters "+set.Name, html, "")
+}
+
+func hitClass(rarity string) string {
+	if baseRarities[rarity] {
+		return ""
+	}
+	return " hit"
+}
+
+func rarityLabel(rarity string) string {
+	if rarity == "" {
+		return "Rareté inconnue"
+	}
+	return rarity
+}
+
+func renderBoxStats(stats BoxStats, sim *boosterSim, setSize int) string {
+	rarities := make([]string, 0, len(stats.ByRarity))
+	for rarity := range stats.ByRarity {
+		rarities = append(rarities, rarity)
+	}
+	sort.Slice(rarities, func(i, j int) bool {
+		return stats.ByRarity[rarities[i]] > stats.ByRarity[rarities[j]]
+	})
+
+	var points []ChartPoint
+	html := `
+        <section class="analytics-section booster-stats">
+            <h3>Statistiques sur ` + strconv.Itoa(stats.Boxes) + ` display(s) (` + strconv.Itoa(stats.Packs) + ` boosters)</h3>
+            <table class="deck-table">
+                <tr><th>Rareté</th><th>Total</th><th>Moyenne par display</th></tr>`
+	for _, rarity := range rarities {
+		perBox := float64(stats.ByRarity[rarity]) / float64(stats.Boxes)
+		points = append(points, ChartPoint{Label: rarityLabel(rarity), Value: perBox})
+		html += `
+                <tr><td>` + escape(rarityLabel(rarity)) + `</td><td>` + strconv.Itoa(stats.ByRarity[rarity]) + `</td><td>` + strconv.FormatFloat(perBox, 'f', 2, 64) + `</td></tr>`
+	}
+	html += `
+            </table>` + barChartSVG("Cartes par display selon la rareté", points)
+
+	hits, minHits, maxHits := 0, stats.HitsPerBox[0], stats.HitsPerBox[0]
+	for _, h := range stats.HitsPerBox {
+		hits += h
+		if h < minHits {
+			minHits = h
+		}
+		if h > maxHits {
+			maxHits = h
+		}
+	}
+
+	html += `
+            <ul class="analytics-odds">
+                <li>Hits par display : <strong>` + strconv.FormatFloat(float64(hits)/float64(stats.Boxes), 'f', 2, 64) + `</strong> en moyenne (min ` + strconv.Itoa(minHits) + `, max ` + strconv.Itoa(maxHits) + `)</li>
+                <li>Cartes différentes obtenues : <strong>` + strconv.Itoa(stats.Unique) + `/` + strconv.Itoa(setSize) + `</strong></li>`
+	if stats.CompletedAtPack > 0 {
+		html += `
+                <li>Set atteignable complété au booster n° <strong>` + strconv.Itoa(stats.CompletedAtPack) + `</strong></li>`
+	}
+
+	if unreachable := setSize - sim.reachableCount(); unreachable > 0 {
+		html += `
+                <li>` + strconv.Itoa(unreachable) + ` carte(s) ne peuvent pas être tirées avec cette configuration et sont exclues de la complétion.</li>`
+	}
+
+	if len(stats.CompletionPacks) > 0 {
+		total, completed := 0, 0
+		minPacks, maxPacks := -1, 0
+		for _, p := range stats.CompletionPacks {
+			if p < 0 {
+				continue
+			}
+			completed++
+			total += p
+			if minPacks < 0 || p < minPacks {
+				minPacks = p
+			}
+			if p > maxPacks {
+				maxPacks = p
+			}
+		}
+		if completed > 0 {
+			html += `
+                <li>Boosters nécessaires pour compléter le set : <strong>` + strconv.Itoa(total/completed) + `</strong> en moyenne sur ` + strconv.Itoa(completed) + ` essai(s) (min ` + strconv.Itoa(minPacks) + `, max ` + strconv.Itoa(maxPacks) + `)</li>`
+		}
+		if failed := len(stats.CompletionPacks) - completed; failed > 0 {
+			html += `
+                <li>` + strconv.Itoa(failed) + ` essai(s) n'ont pas complété le set en ` + strconv.Itoa(maxCompletionPacks) + ` boosters.</li>`
+		}
+	}
+
+	html += `
+            </ul>
+        </section>`
+	return html
+}
+
+func renderBoosterConfig(config BoosterConfig) string {
+	html := `
+        <section class="analytics-section">
+            <h3>Composition d'un booster (` + strconv.Itoa(config.PacksPerBox) + ` boosters par display)</h3>
+            <table class="deck-table">
+                <tr><th>Emplacement</th><th>Cartes</th><th>Raretés (poids)</th></tr>`
+	for _, slot := range config.Slots {
+		rarities := make([]string, 0, len(slot.Rarities))
+		for rarity := range slot.Rarities {
+			rarities = append(rarities, rarity)
+		}
+		sort.Strings(rarities)
+
+		weights := ""
+		for i, rarity := range rarities {
+			if i > 0 {
+				weights += ", "
+			}
+			weights += escape(rarity) + ` (` + strconv.FormatFloat(slot.Rarities[rarity], 'g', -1, 64) + `)`
+		}
+		html += `
+                <tr><td>` + escape(slot.Name) + `</td><td>` + strconv.Itoa(slot.Count) + `</td><td>` + weights + `</td></tr>`
+	}
+	return html + `
+            </table>
+        </section>`
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// errUpstreamUnavailable signale que l'API TCGdex ne peut pas être jointe pour le moment.
+var errUpstreamUnavailable = errors.New("l'API TCGdex est momentanément indisponible")
+
+// États du disjoncteur.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker coupe les appels à l'API après Threshold échecs consécutifs. Une fois ouvert,
+// il rejette immédiatement les appels pendant Cooldown, puis laisse passer une seule requête
+// d'essai (semi-ouvert) : sa réussite referme le disjoncteur, son échec le rouvre.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	changedAt time.Time
+	probing   bool
+	lastError string
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed, changedAt: time.Now()}
+}
+
+// upstreamBreaker protège les appels à l'API TCGdex ; il est recréé au démarrage selon la configuration.
+var upstreamBreaker = newCircuitBreaker(5, 30*time.Second)
+
+func (b *CircuitBreaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	level := slog.LevelWarn
+	if state == breakerClosed {
+		level = slog.LevelInfo
+	}
+	slog.Log(context.Background(), level, "Disjoncteur de l'API", "from", b.state, "to", state, "failures", b.failures)
+	b.state = state
+	b.changedAt = time.Now()
+}
+
+// Allow indique si un appel peut partir. Il renvoie une erreur enveloppant errUpstreamUnavailable
+// tant que le disjoncteur est ouvert, ou semi-ouvert avec un essai déjà en cours.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		wait := b.cooldown - time.Since(b.openedAt)
+		if wait > 0 {
+			return fmt.Errorf("%w (nouvel essai dans %s)", errUpstreamUnavailable, wait.Round(time.Second))
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w (vérification en cours)", errUpstreamUnavailable)
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// Record enregistre le résultat d'un appel autorisé par Allow. Seules les pannes de l'API
+// (réseau, délai dépassé, 5xx, 429) comptent comme des échecs : une 404 prouve que l'API répond.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+
+	switch {
+	case err == nil || !isRetryable(err):
+		if errors.Is(err, context.Canceled) {
+			// Requête abandonnée par le client : rien n'est appris sur l'API.
+			return
+		}
+		b.failures = 0
+		b.lastError = ""
+		b.setState(breakerClosed)
+	default:
+		b.failures++
+		b.lastError = err.Error()
+		if b.state == breakerHalfOpen || b.failures >= b.threshold {
+			b.openedAt = time.Now()
+			b.setState(breakerOpen)
+		}
+	}
+}
+
+// BreakerStatus est l'état du disjoncteur exposé par /health.
+type BreakerStatus struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"consecutiveFailures"`
+	Since     time.Time  `json:"since"`
+	RetryAt   *time.Time `json:"retryAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// currentState renvoie l'état du disjoncteur à l'instant présent : un disjoncteur ouvert dont
+// l'attente est écoulée est semi-ouvert, même si aucun appel ne l'a encore fait basculer.
+func (b *CircuitBreaker) currentState() string {
+	if b.state == breakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return breakerHalfOpen
+	}
+	return b.state
+}
+
+// Available indique si un appel serait autorisé par Allow, sans consommer l'essai du
+// disjoncteur semi-ouvert : fermé, ou prêt pour une requête d'essai.
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		return !b.probing
+	}
+	return true
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.currentState(), Failures: b.failures, Since: b.changedAt, LastError: b.lastError}
+	switch {
+	case status.State == breakerOpen:
+		retryAt := b.openedAt.Add(b.cooldown)
+		status.RetryAt = &retryAt
+	case b.state == breakerOpen:
+		status.Since = b.openedAt.Add(b.cooldown)
+	}
+	return status
+}
+
+// upstreamDegraded indique si l'application fonctionne en mode dégradé : l'API est indisponible
+// et aucun appel ne peut partir. Une fois l'attente du disjoncteur écoulée, le prochain appel
+// sert d'essai et le mode dégradé est levé en attendant son résultat.
+func upstreamDegraded() bool {
+	return !upstreamBreaker.Available()
+}
+
+// requireFreshData refuse les actions qui doivent valider des données auprès de l'API
+// tant que celle-ci est indisponible.
+func requireFreshData() error {
+	if upstreamDegraded() {
+		return fmt.Errorf("%w : cette action est désactivée en attendant son retour", errUpstreamUnavailable)
+	}
+	return nil
+}
+
+// degradedBanner renvoie le bandeau affiché sur toutes les pages en mode dégradé.
+func degradedBanner() string {
+	if !upstreamDegraded() {
+		return ""
+	}
+	return `
+    <div class="degraded-banner">
+        <div class="container">
+            L'API TCGdex est momentanément indisponible : les données affichées peuvent dater, et l'ajout de cartes est désactivé.
+        </div>
+    </div>`
+}
+
+func degradedBannerHTML() template.HTML {
+	return template.HTML(degradedBanner())
+}
+
+// Nombre maximum de réponses de secours conservées.
+const staleResponsesLimit = 500
+
+// staleResponses conserve la dernière réponse valide des listes de l'API (cartes, sets, types...),
+// servie lorsque l'API est indisponible.
+var staleResponses = struct {
+	sync.RWMutex
+	bodies map[string][]byte
+}{bodies: make(map[string][]byte)}
+
+// fetchJSONWithFallback se comporte comme fetchJSON mais, si l'API est indisponible,
+// décode la dernière réponse valide reçue pour cette URL.
+func fetchJSONWithFallback(ctx context.Context, apiURL string, target interface{}) error {
+	var raw json.RawMessage
+	err := fetchJSON(ctx, apiURL, &raw)
+	if err == nil {
+		staleResponses.Lock()
+		if _, ok := staleResponses.bodies[apiURL]; !ok && len(staleResponses.bodies) >= staleResponsesLimit {
+			for key := range staleResponses.bodies {
+				delete(staleResponses.bodies, key)
+				break
+			}
+		}
+		staleResponses.bodies[apiURL] = raw
+		staleResponses.Unlock()
+		return decodeJSON(raw, target)
+	}
+
+	if isNotFound(err) || ctx.Err() != nil {
+		return err
+	}
+	staleResponses.RLock()
+	body, ok := staleResponses.bodies[apiURL]
+	staleResponses.RUnlock()
+	if !ok {
+		return err
+	}
+	slog.WarnContext(ctx, "API indisponible, réponse de secours servie", "url", apiURL, "err", err)
+	return decodeJSON(body, target)
+}
+
+func decodeJSON(body []byte, target interface{}) error {
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("réponse API illisible: %w", err)
+	}
+	return nil
+}
+
+// healthHandler gère /health : état de l'application et du disjoncteur de l'API, en JSON.
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	status := "ok"
+	if upstreamDegraded() {
+		status = "degraded"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		Status     string                   `json:"status"`
+		Upstream   BreakerStatus            `json:"upstream"`
+		Coalescing CoalescingStats          `json:"coalescing"`
+		RateLimit  map[string]LimiterStatus `json:"rateLimit"`
+		Inbound    ClientLimiterStatus      `json:"inboundRateLimit"`
+	}{status, upstreamBreaker.Status(), upstreamFlights.Stats(), upstreamLimiter.Status(), clientLimiter.Status()})
+}
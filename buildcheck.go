@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReprintUse indique combien d'exemplaires d'une réimpression possédée couvrent un besoin.
+type ReprintUse struct {
+	CardID string
+	Count  int
+}
+
+// BuildNeed compare une carte demandée par la liste de deck à ce que l'on possède.
+type BuildNeed struct {
+	CardID   string
+	Card     Card
+	Needed   int
+	Owned    int
+	Reprints []ReprintUse
+}
+
+// Covered renvoie le nombre d'exemplaires couverts, réimpressions comprises.
+func (n BuildNeed) Covered() int {
+	covered := n.Owned
+	for _, r := range n.Reprints {
+		covered += r.Count
+	}
+	return covered
+}
+
+// Missing renvoie le nombre d'exemplaires encore à acquérir.
+func (n BuildNeed) Missing() int {
+	return n.Needed - n.Covered()
+}
+
+// buildAvailability renvoie les exemplaires disponibles par ID de carte selon la cible :
+// la collection possédée, ou une liste de favoris (un exemplaire par carte).
+func buildAvailability(target string) (map[string]int, string, error) {
+	if target == "collection" {
+		collection, err := loadCollection()
+		if err != nil {
+			return nil, "", err
+		}
+		return collection.Quantities(), "Ma collection", nil
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		return nil, "", err
+	}
+	list := favorites.List(target)
+	if list == nil {
+		return nil, "", errListNotFound
+	}
+
+	available := make(map[string]int)
+	for _, id := range list.IDs() {
+		available[id] = 1
+	}
+	return available, "Liste « " + list.Name + " »", nil
+}
+
+// attackSignature résume le texte de jeu d'une carte : deux impressions de même nom
+// et de même signature sont fonctionnellement identiques.
+func attackSignature(card Card) string {
+	parts := []string{normalizeCardName(card.Effect)}
+	for _, attack := range card.Attacks {
+		parts = append(parts, strings.Join([]string{
+			normalizeCardName(attack.Name),
+			strings.Join(attack.Cost, "+"),
+			fmt.Sprint(attack.Damage),
+			normalizeCardName(attack.Effect),
+		}, "/"))
+	}
+	return strings.Join(parts, "|")
+}
+
+// findReprints renvoie les IDs des cartes possédées qui sont des réimpressions de card.
+func findReprints(ctx context.Context, card Card, available map[string]int) []string {
+	printings, _, err := fetchCards(ctx, 1, 1000, map[string]string{"name": card.Name})
+	if err != nil {
+		slog.WarnContext(ctx, "Impossible de rechercher les réimpressions", "card", card.Name, "err", err)
+		return nil
+	}
+
+	name := normalizeCardName(card.Name)
+	signature := attackSignature(card)
+
+	var reprints []string
+	for _, printing := range printings {
+		if printing.ID == card.ID || available[printing.ID] == 0 || normalizeCardName(printing.Name) != name {
+			continue
+		}
+		detailed, err := getCard(ctx, printing.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "Impossible de récupérer la carte", "card", printing.ID, "err", err)
+			continue
+		}
+		if attackSignature(detailed) == signature {
+			reprints = append(reprints, printing.ID)
+		}
+	}
+	sort.Strings(reprints)
+	return reprints
+}
+
+// computeBuildNeeds confronte les lignes résolues de la liste aux exemplaires disponibles.
+// Un exemplaire possédé n'est compté qu'une fois, qu'il couvre sa propre carte ou une réimpression.
+func computeBuildNeeds(ctx context.Context, lines []DeckLine, available map[string]int) []BuildNeed {
+	var needs []BuildNeed
+	index := make(map[string]int)
+	for _, line := range lines {
+		if line.CardID == "" {
+			continue
+		}
+		if i, ok := index[line.CardID]; ok {
+			needs[i].Needed += line.Count
+			continue
+		}
+		index[line.CardID] = len(needs)
+		needs = append(needs, BuildNeed{CardID: line.CardID, Needed: line.Count})
+	}
+
+	ids := make([]string, len(needs))
+	for i, need := range needs {
+		ids[i] = need.CardID
+	}
+	for i, h := range hydrateCards(ctx, ids) {
+		needs[i].Card = h.Card
+		if needs[i].Card.Name == "" {
+			needs[i].Card.Name = h.ID
+		}
+	}
+
+	remaining := make(map[string]int, len(available))
+	for id, count := range available {
+		remaining[id] = count
+	}
+
+	for i := range needs {
+		need := &needs[i]
+		need.Owned = minInt(need.Needed, remaining[need.CardID])
+		remaining[need.CardID] -= need.Owned
+	}
+
+	for i := range needs {
+		need := &needs[i]
+		if need.Missing() == 0 || need.Card.ID == "" {
+			continue
+		}
+		for _, id := range findReprints(ctx, need.Card, remaining) {
+			count := minInt(need.Missing(), remaining[id])
+			if count == 0 {
+				continue
+			}
+			need.Reprints = append(need.Reprints, ReprintUse{CardID: id, Count: count})
+			remaining[id] -= count
+			if need.Missing() == 0 {
+				break
+			}
+		}
+	}
+
+	return needs
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// buildCheckHandler gère /build : ce qu'il manque pour construire une liste de deck.
+// La liste est collée (list) ou reprise d'un deck enregistré (?deck=), et confrontée
+// à la collection ou à une liste (against). format=txt|csv exporte la liste de courses.
+func buildCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	text := r.FormValue("list")
+	if slug := r.FormValue("deck"); slug != "" && strings.TrimSpace(text) == "" {
+		decks, err := loadDecks()
+		if err != nil {
+			showError(w, "Impossible de charger les decks", err)
+			return
+		}
+		deck := decks.Deck(slug)
+		if deck == nil {
+			showError(w, "Deck introuvable", fmt.Errorf("aucun deck %s", slug))
+			return
+		}
+		text = formatDeckList(ctx, *deck, hydrateDeck(ctx, *deck))
+	}
+
+	against := r.FormValue("against")
+	if against == "" {
+		against = "collection"
+	}
+
+	if strings.TrimSpace(text) == "" {
+		writePage(w, "Ce qu'il me manque", `
+        <div class="page-header">
+            <h2>Ce qu'il me manque</h2>
+            <p class="results-count">Collez une liste de deck pour la comparer à vos cartes</p>
+        </div>`+renderBuildForm(text, against), "")
+		return
+	}
+
+	available, label, err := buildAvailability(against)
+	if err != nil {
+		showError(w, "Impossible de charger les cartes possédées", err)
+		return
+	}
+
+	lines := parseDeckList(text)
+	resolveDeckLines(ctx, lines)
+	needs := computeBuildNeeds(ctx, lines, available)
+
+	var unresolved []DeckLine
+	for _, line := range lines {
+		if line.CardID == "" {
+			unresolved = append(unresolved, line)
+		}
+	}
+
+	switch r.FormValue("format") {
+	case "txt":
+		writeShoppingListText(ctx, w, needs, unresolved)
+		return
+	case "csv":
+		writeShoppingListCSV(ctx, w, needs, unresolved)
+		return
+	}
+
+	var owned, reprinted, missing []BuildNeed
+	needed, covered := 0, 0
+	for _, need := range needs {
+		needed += need.Needed
+		covered += need.Covered()
+		if need.Owned > 0 {
+			owned = append(owned, need)
+		}
+		if len(need.Reprints) > 0 {
+			reprinted = append(reprinted, need)
+		}
+		if need.Missing() > 0 {
+			missing = append(missing, need)
+		}
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Ce qu'il me manque</h2>
+            <p class="results-count">Comparé à ` + escape(label) + ` : ` + strconv.Itoa(covered) + `/` + strconv.Itoa(needed) + ` cartes couvertes</p>
+        </div>`
+
+	if len(unresolved) > 0 {
+		html += `
+        <section class="diff-section unresolved">
+            <h3>Lignes non résolues</h3>
+            <ul>`
+		for _, line := range unresolved {
+			html += `
+                <li>Ligne ` + strconv.Itoa(line.Line) + ` « ` + escape(line.Raw) + ` » : ` + escape(line.Problem)
+			if len(line.Candidates) > 0 {
+				html += ` — candidates : ` + escape(strings.Join(line.Candidates, ", "))
+			}
+			html += `</li>`
+		}
+		html += `
+            </ul>
+        </section>`
+	}
+
+	html += `
+        <section class="diff-section removed">
+            <h3>Cartes manquantes (` + strconv.Itoa(needed-covered) + `)</h3>`
+	if len(missing) == 0 && len(unresolved) == 0 {
+		html += `
+            <p>Rien ne manque : vous pouvez construire ce deck.</p>`
+	} else {
+		html += `
+            <ul>`
+		for _, need := range missing {
+			html += `
+                <li>` + strconv.Itoa(need.Missing()) + ` × <a href="/card/` + escape(need.CardID) + `">` + escape(need.Card.Name) + `</a> (` + escape(need.CardID) + `)</li>`
+		}
+		html += `
+            </ul>
+            <form action="/build" method="POST" class="list-form">
+                <input type="hidden" name="list" value="` + escape(text) + `">
+                <input type="hidden" name="against" value="` + escape(against) + `">
+                <button type="submit" name="format" value="txt" class="button secondary">Liste de courses (texte)</button>
+                <button type="submit" name="format" value="csv" class="button secondary">Liste de courses (CSV)</button>
+            </form>`
+	}
+	html += `
+        </section>`
+
+	if len(reprinted) > 0 {
+		html += `
+        <section class="diff-section updated">
+            <h3>Réimpressions possédées</h3>
+            <ul>`
+		for _, need := range reprinted {
+			var uses []string
+			for _, use := range need.Reprints {
+				uses = append(uses, strconv.Itoa(use.Count)+` × <a href="/card/`+escape(use.CardID)+`">`+escape(use.CardID)+`</a>`)
+			}
+			html += `
+                <li>` + escape(need.Card.Name) + ` (` + escape(need.CardID) + `) : ` + strings.Join(uses, ", ") + `</li>`
+		}
+		html += `
+            </ul>
+        </section>`
+	}
+
+	html += `
+        <section class="diff-section added">
+            <h3>Cartes possédées</h3>`
+	if len(owned) == 0 {
+		html += `
+            <p>Aucune carte de la liste n'est possédée telle quelle.</p>`
+	} else {
+		html += `
+            <ul>`
+		for _, need := range owned {
+			html += `
+                <li>` + strconv.Itoa(need.Owned) + `/` + strconv.Itoa(need.Needed) + ` × <a href="/card/` + escape(need.CardID) + `">` + escape(need.Card.Name) + `</a></li>`
+		}
+		html += `
+            </ul>`
+	}
+	html += `
+        </section>` + renderBuildForm(text, against)
+
+	writePage(w, "Ce qu'il me manque", html, "")
+}
+
+func renderBuildForm(text, against string) string {
+	options := `<option value="collection"` + selected(against == "collection") + `>Ma collection</option>`
+	if favorites, err := loadFavorites(); err == nil {
+		for _, list := range favorites.Lists {
+			options += `<option value="` + escape(list.Slug) + `"` + selected(against == list.Slug) + `>Liste « ` + escape(list.Name) + ` » (1 exemplaire par carte)</option>`
+		}
+	}
+
+	return `
+        <form action="/build" method="POST" class="list-form import-form">
+            <h3>Liste de deck (Pokémon TCG Live / PTCGO)</h3>
+            <textarea name="list" rows="10" placeholder="Pokémon: 4&#10;4 Charizard ex OBF 125&#10;Trainer: 4&#10;4 Rare Candy SVI 191">` + escape(text) + `</textarea>
+            <select name="against">` + options + `</select>
+            <button type="submit" class="button">Comparer</button>
+        </form>`
+}
+
+// writeShoppingListText exporte les cartes manquantes au format Pokémon TCG Live.
+// Les lignes non résolues sont reprises telles quelles.
+func writeShoppingListText(ctx context.Context, w http.ResponseWriter, needs []BuildNeed, unresolved []DeckLine) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="liste-de-courses.txt"`)
+
+	total := 0
+	for _, need := range needs {
+		if n := need.Missing(); n > 0 {
+			fmt.Fprintf(w, "%d %s %s %s\n", n, need.Card.Name, cardSetCode(ctx, need.Card), need.Card.LocalId)
+			total += n
+		}
+	}
+	for _, line := range unresolved {
+		fmt.Fprintln(w, line.Raw)
+		total += line.Count
+	}
+	fmt.Fprintf(w, "\nTotal Cards: %d\n", total)
+}
+
+// writeShoppingListCSV exporte les cartes manquantes en CSV.
+func writeShoppingListCSV(ctx context.Context, w http.ResponseWriter, needs []BuildNeed, unresolved []DeckLine) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="liste-de-courses.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"quantity", "name", "set_code", "number", "card_id"})
+	for _, need := range needs {
+		if n := need.Missing(); n > 0 {
+			writer.Write([]string{strconv.Itoa(n), need.Card.Name, cardSetCode(ctx, need.Card), need.Card.LocalId, need.CardID})
+		}
+	}
+	for _, line := range unresolved {
+		writer.Write([]string{strconv.Itoa(line.Count), line.Name, line.SetCode, line.Number, ""})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Durée pendant laquelle une carte récupérée auprès de l'API reste valide dans le cache.
+const cardCacheTTL = time.Hour
+
+// Nombre maximal de requêtes simultanées lors de l'hydratation d'une liste de cartes.
+const hydrateWorkers = 6
+
+type cachedCard struct {
+	card      Card
+	fetchedAt time.Time
+}
+
+// cardCache conserve en mémoire les cartes détaillées déjà récupérées, indexées par ID.
+type cardCache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	cards map[string]cachedCard
+}
+
+var catalogue = newCardCache(cardCacheTTL)
+
+func newCardCache(ttl time.Duration) *cardCache {
+	return &cardCache{
+		ttl:   ttl,
+		cards: make(map[string]cachedCard),
+	}
+}
+
+func (c *cardCache) get(id string) (Card, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.cards[id]
+	if !ok || time.Since(entry.fetchedAt) > c.ttl {
+		return Card{}, false
+	}
+	return entry.card, true
+}
+
+// getStale renvoie la carte en cache même expirée, pour le mode dégradé.
+func (c *cardCache) getStale(id string) (Card, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.cards[id]
+	return entry.card, ok
+}
+
+func (c *cardCache) put(card Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cards[card.ID] = cachedCard{card: card, fetchedAt: time.Now()}
+}
+
+// getCard renvoie la carte depuis le cache ou la récupère auprès de l'API en cas d'absence.
+// Si l'API est indisponible, une carte expirée du cache est renvoyée plutôt qu'une erreur.
+func getCard(ctx context.Context, id string) (Card, error) {
+	if card, ok := catalogue.get(id); ok {
+		return card, nil
+	}
+
+	card, err := fetchCard(ctx, id)
+	if err != nil {
+		if stale, ok := catalogue.getStale(id); ok && !isNotFound(err) && ctx.Err() == nil {
+			return stale, nil
+		}
+		return card, err
+	}
+
+	catalogue.put(card)
+	return card, nil
+}
+
+// setCache conserve les détails des sets déjà récupérés, indexés par ID.
+var setCache = struct {
+	sync.RWMutex
+	sets map[string]cachedSet
+}{sets: make(map[string]cachedSet)}
+
+type cachedSet struct {
+	set       Set
+	fetchedAt time.Time
+}
+
+// getSet renvoie le set depuis le cache ou le récupère auprès de l'API en cas d'absence.
+// Si l'API est indisponible, un set expiré du cache est renvoyé plutôt qu'une erreur.
+func getSet(ctx context.Context, id string) (Set, error) {
+	setCache.RLock()
+	entry, ok := setCache.sets[id]
+	setCache.RUnlock()
+	if ok && time.Since(entry.fetchedAt) <= cardCacheTTL {
+		return entry.set, nil
+	}
+
+	set, err := fetchSet(ctx, id)
+	if err != nil {
+		if ok && !isNotFound(err) && ctx.Err() == nil {
+			return entry.set, nil
+		}
+		return set, err
+	}
+
+	setCache.Lock()
+	setCache.sets[id] = cachedSet{set: set, fetchedAt: time.Now()}
+	setCache.Unlock()
+	return set, nil
+}
+
+// HydratedCard associe une référence de carte à ses données issues du catalogue.
+// Unknown est vrai lorsque l'API ne connaît plus la carte, Err contient toute autre erreur.
+type HydratedCard struct {
+	ID      string
+	Card    Card
+	Unknown bool
+	Err     error
+}
+
+// hydrateCards récupère les données de chaque carte référencée, en conservant l'ordre.
+// Les cartes absentes du cache sont récupérées en parallèle.
+func hydrateCards(ctx context.Context, ids []string) []HydratedCard {
+	return hydrateCardsWith(ctx, ids, getCard)
+}
+
+// hydrateCardsWith fonctionne comme hydrateCards mais récupère les cartes absentes du cache
+// avec get. La reconstruction de l'index passe fetchCard pour ne pas remplir le cache.
+func hydrateCardsWith(ctx context.Context, ids []string, get func(context.Context, string) (Card, error)) []HydratedCard {
+	results := make([]HydratedCard, len(ids))
+	var misses []int
+
+	for i, id := range ids {
+		results[i].ID = id
+		if card, ok := catalogue.get(id); ok {
+			results[i].Card = card
+		} else {
+			misses = append(misses, i)
+		}
+	}
+
+	if len(misses) == 0 {
+		return results
+	}
+
+	slog.DebugContext(ctx, "Hydratation de cartes absentes du cache", "count", len(misses))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := hydrateWorkers
+	if len(misses) < workers {
+		workers = len(misses)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				card, err := get(ctx, results[i].ID)
+				if err != nil {
+					if isNotFound(err) {
+						results[i].Unknown = true
+					} else {
+						results[i].Err = err
+					}
+					slog.WarnContext(ctx, "Impossible d'hydrater la carte", "card", results[i].ID, "err", err)
+					continue
+				}
+				results[i].Card = card
+			}
+		}()
+	}
+
+	// Les cartes restantes ne sont plus demandées une fois ctx annulé.
+	for n, i := range misses {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		for _, j := range misses[n:] {
+			results[j].Err = ctx.Err()
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version actuelle du format de data/catalogue.json. Un index d'une version antérieure est
+// reconstruit entièrement.
+const catalogueIndexSchemaVersion = 2
+
+// Fichier de l'index du catalogue, dans le dossier de données.
+const catalogueIndexFile = "catalogue.json"
+
+// Nombre de sets traités entre deux sauvegardes intermédiaires de l'index.
+const catalogueCheckpointEvery = 10
+
+// IndexedCard est la fiche compacte d'une carte conservée dans l'index du catalogue.
+type IndexedCard struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	LocalID        string   `json:"localId,omitempty"`
+	SetID          string   `json:"setId"`
+	Image          string   `json:"image,omitempty"`
+	Category       string   `json:"category,omitempty"`
+	Rarity         string   `json:"rarity,omitempty"`
+	Types          []string `json:"types,omitempty"`
+	HP             int      `json:"hp,omitempty"`
+	Stage          string   `json:"stage,omitempty"`
+	EnergyType     string   `json:"energyType,omitempty"`
+	Illustrator    string   `json:"illustrator,omitempty"`
+	RegulationMark string   `json:"regulationMark,omitempty"`
+	DexIDs         []int    `json:"dexId,omitempty"`
+	Legal          Legal    `json:"legal,omitempty"`
+	// GroupKey (catégorie et nom du Pokémon) et Signature (talent et attaques) servent à
+	// retrouver les autres versions d'une carte sans la récupérer en détail.
+	GroupKey  string `json:"groupKey,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Card reconstruit une carte partielle à partir de sa fiche d'index.
+func (c IndexedCard) Card() Card {
+	return Card{
+		ID:             c.ID,
+		Name:           c.Name,
+		LocalId:        c.LocalID,
+		Set:            Set{ID: c.SetID},
+		Image:          c.Image,
+		Category:       c.Category,
+		Rarity:         c.Rarity,
+		Types:          c.Types,
+		HP:             c.HP,
+		Stage:          c.Stage,
+		EnergyType:     c.EnergyType,
+		Illustrator:    c.Illustrator,
+		RegulationMark: c.RegulationMark,
+		DexID:          c.DexIDs,
+		Legal:          c.Legal,
+	}
+}
+
+func indexCard(card Card) IndexedCard {
+	hp, _ := strconv.Atoi(card.GetHP())
+	return IndexedCard{
+		ID:             card.ID,
+		Name:           card.Name,
+		LocalID:        card.LocalId,
+		SetID:          card.Set.ID,
+		Image:          card.Image,
+		Category:       card.Category,
+		Rarity:         card.Rarity,
+		Types:          card.Types,
+		HP:             hp,
+		Stage:          card.Stage,
+		EnergyType:     card.EnergyType,
+		Illustrator:    cardIllustrator(card),
+		RegulationMark: card.RegulationMark,
+		DexIDs:         card.DexID,
+		Legal:          card.Legal,
+		GroupKey:       versionGroupKey(card.Category, card.Name),
+		Signature:      attackSignature(card),
+	}
+}
+
+// IndexedSet est la fiche compacte d'un set conservée dans l'index du catalogue.
+type IndexedSet struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Legal       Legal  `json:"legal,omitempty"`
+	Total       int    `json:"total,omitempty"`
+}
+
+// Year renvoie l'année de sortie du set, ou une chaîne vide si elle est inconnue.
+func (s IndexedSet) Year() string {
+	if len(s.ReleaseDate) < 4 {
+		return ""
+	}
+	return s.ReleaseDate[:4]
+}
+
+// CatalogueIndex est le contenu de data/catalogue.json : tous les sets et toutes les cartes,
+// sous forme compacte, pour les statistiques et les regroupements sur l'ensemble du catalogue.
+type CatalogueIndex struct {
+	Version   int           `json:"version"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Sets      []IndexedSet  `json:"sets"`
+	Cards     []IndexedCard `json:"cards"`
+
+	versions *versionLookup
+}
+
+// Set renvoie la fiche d'un set de l'index.
+func (c CatalogueIndex) Set(id string) (IndexedSet, bool) {
+	for _, set := range c.Sets {
+		if set.ID == id {
+			return set, true
+		}
+	}
+	return IndexedSet{}, false
+}
+
+// catalogueIndex conserve l'index en mémoire et l'état de sa reconstruction.
+var catalogueIndex = struct {
+	sync.RWMutex
+	index      *CatalogueIndex
+	refreshing bool
+	progress   string
+	lastError  error
+}{}
+
+func loadCatalogueIndex() (CatalogueIndex, error) {
+	index := CatalogueIndex{Version: catalogueIndexSchemaVersion}
+
+	data, err := os.ReadFile(dataPath(catalogueIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, err
+	}
+
+	if len(data) == 0 {
+		return index, nil
+	}
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return CatalogueIndex{Version: catalogueIndexSchemaVersion}, fmt.Errorf("index du catalogue invalide: %w", err)
+	}
+
+	return index, nil
+}
+
+func saveCatalogueIndex(index CatalogueIndex) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	index.Version = catalogueIndexSchemaVersion
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(catalogueIndexFile), data, 0644)
+}
+
+// currentCatalogueIndex renvoie l'index en mémoire, chargé depuis le disque au premier appel.
+func currentCatalogueIndex() CatalogueIndex {
+	catalogueIndex.RLock()
+	index := catalogueIndex.index
+	catalogueIndex.RUnlock()
+	if index != nil {
+		return *index
+	}
+
+	loaded, err := loadCatalogueIndex()
+	if err != nil {
+		slog.Error("Erreur lors du chargement de l'index du catalogue", "err", err)
+	}
+
+	catalogueIndex.Lock()
+	defer catalogueIndex.Unlock()
+	if catalogueIndex.index == nil {
+		loaded.versions = newVersionLookup(loaded.Cards)
+		catalogueIndex.index = &loaded
+	}
+	return *catalogueIndex.index
+}
+
+// catalogueIndexStatus renvoie l'état de la reconstruction en cours.
+func catalogueIndexStatus() (bool, string, error) {
+	catalogueIndex.RLock()
+	defer catalogueIndex.RUnlock()
+	return catalogueIndex.refreshing, catalogueIndex.progress, catalogueIndex.lastError
+}
+
+func setCatalogueProgress(progress string) {
+	catalogueIndex.Lock()
+	catalogueIndex.progress = progress
+	catalogueIndex.Unlock()
+}
+
+// refreshCatalogueIndex reconstruit l'index set par set. Seules les cartes absentes de l'index
+// sont récupérées en détail ; un set en erreur conserve ses cartes déjà indexées.
+// L'index est sauvegardé régulièrement, et à l'annulation de ctx, pour qu'une reconstruction
+// interrompue reprenne où elle s'est arrêtée.
+func refreshCatalogueIndex(ctx context.Context) error {
+	catalogueIndex.Lock()
+	if catalogueIndex.refreshing {
+		catalogueIndex.Unlock()
+		return fmt.Errorf("reconstruction de l'index déjà en cours")
+	}
+	catalogueIndex.refreshing = true
+	catalogueIndex.lastError = nil
+	catalogueIndex.Unlock()
+
+	err := rebuildCatalogueIndex(ctx)
+
+	catalogueIndex.Lock()
+	catalogueIndex.refreshing = false
+	catalogueIndex.progress = ""
+	catalogueIndex.lastError = err
+	catalogueIndex.Unlock()
+
+	return err
+}
+
+func rebuildCatalogueIndex(ctx context.Context) error {
+	if err := requireFreshData(); err != nil {
+		return err
+	}
+
+	previous := currentCatalogueIndex()
+
+	known := make(map[string]IndexedCard, len(previous.Cards))
+	bySet := make(map[string][]IndexedCard)
+	for _, card := range previous.Cards {
+		bySet[card.SetID] = append(bySet[card.SetID], card)
+		// Les fiches d'un ancien format sont récupérées à nouveau pour compléter les champs ajoutés.
+		if previous.Version == catalogueIndexSchemaVersion {
+			known[card.ID] = card
+		}
+	}
+
+	sets, err := fetchSets(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := CatalogueIndex{UpdatedAt: previous.UpdatedAt}
+	cards := make(map[string]IndexedCard, len(known))
+
+	snapshot := func() CatalogueIndex {
+		snap := index
+		snap.Cards = make([]IndexedCard, 0, len(cards))
+		for _, card := range cards {
+			snap.Cards = append(snap.Cards, card)
+		}
+		for id, card := range known {
+			if _, ok := cards[id]; !ok {
+				snap.Cards = append(snap.Cards, card)
+			}
+		}
+		sort.Slice(snap.Cards, func(i, j int) bool { return snap.Cards[i].ID < snap.Cards[j].ID })
+		return snap
+	}
+
+	for i, summary := range sets {
+		if ctx.Err() != nil {
+			if err := saveCatalogueIndex(snapshot()); err != nil {
+				slog.ErrorContext(ctx, "Erreur lors de la sauvegarde intermédiaire de l'index", "err", err)
+			}
+			return fmt.Errorf("reconstruction interrompue au set %d/%d: %w", i+1, len(sets), ctx.Err())
+		}
+		setCatalogueProgress(fmt.Sprintf("set %d/%d (%s)", i+1, len(sets), summary.Name))
+
+		entry := IndexedSet{ID: summary.ID, Name: summary.Name, Total: summary.CardCount.Total}
+		if set, err := getSet(ctx, summary.ID); err == nil {
+			entry.ReleaseDate = set.ReleaseDate
+			entry.Legal = set.Legal
+			if set.CardCount.Total > 0 {
+				entry.Total = set.CardCount.Total
+			}
+		} else {
+			slog.WarnContext(ctx, "Impossible de récupérer le set pour l'index", "set", summary.ID, "err", err)
+		}
+		index.Sets = append(index.Sets, entry)
+
+		setCards, err := fetchSetCards(ctx, summary.ID, 0)
+		if err != nil {
+			slog.WarnContext(ctx, "Impossible de récupérer les cartes du set pour l'index", "set", summary.ID, "err", err)
+			for _, card := range bySet[summary.ID] {
+				cards[card.ID] = card
+			}
+			continue
+		}
+
+		var missing []string
+		for _, card := range setCards {
+			if indexed, ok := known[card.ID]; ok {
+				cards[card.ID] = indexed
+			} else {
+				missing = append(missing, card.ID)
+			}
+		}
+		// Les cartes de tout le catalogue ne sont pas gardées dans le cache des pages, qui
+		// n'expulse jamais ses entrées.
+		for _, h := range hydrateCardsWith(ctx, missing, fetchCard) {
+			if h.Err != nil || h.Unknown {
+				continue
+			}
+			if h.Card.Set.ID == "" {
+				h.Card.Set.ID = summary.ID
+			}
+			cards[h.ID] = indexCard(h.Card)
+		}
+
+		if (i+1)%catalogueCheckpointEvery == 0 {
+			if err := saveCatalogueIndex(snapshot()); err != nil {
+				slog.ErrorContext(ctx, "Erreur lors de la sauvegarde intermédiaire de l'index", "err", err)
+			}
+		}
+	}
+
+	// Les cartes de sets disparus de l'API ne sont pas conservées.
+	known = nil
+	index.UpdatedAt = time.Now()
+	final := snapshot()
+	if err := saveCatalogueIndex(final); err != nil {
+		return err
+	}
+	final.Version = catalogueIndexSchemaVersion
+	final.versions = newVersionLookup(final.Cards)
+
+	catalogueIndex.Lock()
+	catalogueIndex.index = &final
+	catalogueIndex.Unlock()
+
+	slog.InfoContext(ctx, "Index du catalogue reconstruit", "sets", len(final.Sets), "cards", len(final.Cards))
+	return nil
+}
+
+// catalogueIndexJob reconstruit l'index lorsqu'il est absent, d'un ancien format ou plus vieux
+// que ttl, en vérifiant toutes les heures.
+func catalogueIndexJob(ttl time.Duration) Job {
+	return Job{
+		Name: "index du catalogue",
+		Run: func(ctx context.Context) {
+			for {
+				if index := currentCatalogueIndex(); time.Since(index.UpdatedAt) > ttl || index.Version != catalogueIndexSchemaVersion {
+					if err := refreshCatalogueIndex(ctx); err != nil {
+						slog.ErrorContext(ctx, "Erreur lors de la reconstruction de l'index du catalogue", "err", err)
+					}
+				}
+				if !sleepContext(ctx, time.Hour) {
+					return
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// flight est un appel à l'API en cours, partagé par tous les appelants de la même URL.
+// Sa priorité est celle du plus prioritaire de ses appelants : un appel de fond rejoint par
+// un visiteur devient interactif.
+type flight struct {
+	done     chan struct{}
+	body     []byte
+	err      error
+	waiters  int
+	cancel   context.CancelFunc
+	priority atomic.Int32
+}
+
+func (f *flight) Priority() trafficPriority {
+	return trafficPriority(f.priority.Load())
+}
+
+// raise passe l'appel à la priorité p si elle est plus haute que la sienne. Il est appelé sous
+// le verrou du flightGroup ; la priorité reste lue sans verrou par le limiteur.
+func (f *flight) raise(p trafficPriority) {
+	if p < f.Priority() {
+		f.priority.Store(int32(p))
+	}
+}
+
+// flightGroup regroupe les appels simultanés à une même URL : le premier appelant lance la
+// requête, les suivants attendent son résultat au lieu d'interroger l'API à leur tour.
+// La requête partagée n'est annulée que lorsque tous ses appelants ont abandonné.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+
+	fetches   atomic.Int64
+	coalesced atomic.Int64
+}
+
+var upstreamFlights = &flightGroup{flights: make(map[string]*flight)}
+
+// Do renvoie le résultat de fn pour key, en partageant un appel déjà en cours s'il y en a un.
+// Le corps renvoyé est partagé entre les appelants et ne doit pas être modifié.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if ok {
+		f.waiters++
+		f.raise(priorityOf(ctx))
+		g.coalesced.Add(1)
+	} else {
+		// La requête partagée garde l'identifiant de requête du premier appelant, sans dépendre
+		// de son annulation ; sa priorité est lue sur le flight, que les appelants suivants
+		// peuvent relever.
+		f = &flight{done: make(chan struct{}), waiters: 1}
+		f.priority.Store(int32(priorityOf(ctx)))
+		base := context.WithValue(context.Background(), priorityKey{}, f)
+		if id := requestIDFrom(ctx); id != "" {
+			base = withRequestID(base, id)
+		}
+		var flightCtx context.Context
+		flightCtx, f.cancel = context.WithCancel(base)
+		g.flights[key] = f
+		g.fetches.Add(1)
+		go g.run(flightCtx, key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.body, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Plus personne n'attend : la requête est abandonnée et un nouvel appelant en relancera une.
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, fmt.Errorf("requête API abandonnée: %w", ctx.Err())
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(ctx context.Context) ([]byte, error)) {
+	defer f.cancel()
+	body, err := fn(ctx)
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+
+	f.body, f.err = body, err
+	close(f.done)
+}
+
+// CoalescingStats résume le regroupement des appels à l'API, exposé par /health.
+type CoalescingStats struct {
+	Calls     int64 `json:"calls"`
+	Fetches   int64 `json:"upstreamFetches"`
+	Coalesced int64 `json:"coalesced"`
+	InFlight  int   `json:"inFlight"`
+}
+
+func (g *flightGroup) Stats() CoalescingStats {
+	g.mu.Lock()
+	inFlight := len(g.flights)
+	g.mu.Unlock()
+
+	fetches, coalesced := g.fetches.Load(), g.coalesced.Load()
+	return CoalescingStats{Calls: fetches + coalesced, Fetches: fetches, Coalesced: coalesced, InFlight: inFlight}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowAPI est une fausse API qui répond après delay et compte les requêtes reçues.
+type slowAPI struct {
+	*httptest.Server
+	hits atomic.Int64
+}
+
+func newSlowAPI(tb testing.TB, delay time.Duration) *slowAPI {
+	tb.Helper()
+	api := &slowAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.hits.Add(1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	tb.Cleanup(api.Close)
+	return api
+}
+
+// loadWave lance callers appels simultanés répartis sur paths, en les regroupant par URL avec
+// group ou, si group est nil, en interrogeant l'API à chaque appel. Il renvoie le nombre de
+// requêtes reçues par l'API.
+func loadWave(tb testing.TB, api *slowAPI, group *flightGroup, callers int, paths []string) int64 {
+	tb.Helper()
+	before := api.hits.Load()
+	start := make(chan struct{})
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < callers; i++ {
+		url := api.URL + paths[i%len(paths)]
+		fetch := func(ctx context.Context) ([]byte, error) { return fetchJSONOnce(ctx, api.Client(), url) }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			var err error
+			if group != nil {
+				_, err = group.Do(context.Background(), url, fetch)
+			} else {
+				_, err = fetch(context.Background())
+			}
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			tb.Fatalf("appel en échec: %v", err)
+		}
+	}
+	return api.hits.Load() - before
+}
+
+var loadPaths = []string{"/cards", "/cards/sv03-125", "/sets"}
+
+func TestCoalescingReducesUpstreamCalls(t *testing.T) {
+	const callers = 60
+	api := newSlowAPI(t, 200*time.Millisecond)
+
+	without := loadWave(t, api, nil, callers, loadPaths)
+	group := &flightGroup{flights: make(map[string]*flight)}
+	with := loadWave(t, api, group, callers, loadPaths)
+	t.Logf("%d appels simultanés sur %d URL : %d requêtes à l'API sans regroupement, %d avec", callers, len(loadPaths), without, with)
+
+	if without != callers {
+		t.Errorf("%d requêtes sans regroupement, %d attendues", without, callers)
+	}
+	// Tous les appels partent ensemble et l'API met 200 ms à répondre : une requête par URL.
+	if with != int64(len(loadPaths)) {
+		t.Errorf("%d requêtes avec regroupement, %d attendues", with, len(loadPaths))
+	}
+	stats := group.Stats()
+	if stats.Calls != callers || stats.Fetches != with || stats.InFlight != 0 {
+		t.Errorf("compteurs = %+v", stats)
+	}
+}
+
+func TestCoalescingCancelledCallerDoesNotCancelOthers(t *testing.T) {
+	api := newSlowAPI(t, 100*time.Millisecond)
+	group := &flightGroup{flights: make(map[string]*flight)}
+	url := api.URL + "/cards"
+	fetch := func(ctx context.Context) ([]byte, error) { return fetchJSONOnce(ctx, api.Client(), url) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := group.Do(ctx, url, fetch)
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := group.Do(context.Background(), url, fetch)
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-first; err == nil {
+		t.Error("l'appelant annulé doit recevoir une erreur")
+	}
+	if err := <-second; err != nil {
+		t.Errorf("l'appel partagé a été annulé avec le premier appelant: %v", err)
+	}
+	if hits := api.hits.Load(); hits != 1 {
+		t.Errorf("%d requêtes à l'API, 1 attendue", hits)
+	}
+}
+
+// BenchmarkCoalescing mesure les requêtes envoyées à l'API pour des vagues de 50 appels
+// simultanés sur 3 URL, avec et sans regroupement (métrique upstream/op).
+func BenchmarkCoalescing(b *testing.B) {
+	for _, coalesce := range []bool{true, false} {
+		name := "sans-regroupement"
+		if coalesce {
+			name = "avec-regroupement"
+		}
+		b.Run(name, func(b *testing.B) {
+			api := newSlowAPI(b, 20*time.Millisecond)
+			var hits int64
+			for i := 0; i < b.N; i++ {
+				var group *flightGroup
+				if coalesce {
+					group = &flightGroup{flights: make(map[string]*flight)}
+				}
+				hits += loadWave(b, api, group, 50, loadPaths)
+			}
+			b.ReportMetric(float64(hits)/float64(b.N), "upstream/op")
+		})
+	}
+}
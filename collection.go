@@ -0,0 +1,523 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version actuelle du schéma de data/collection.json.
+const collectionSchemaVersion = 1
+
+const collectionFile = "collection.json"
+
+// collectionMu sérialise les cycles lecture-modification-écriture du fichier de collection.
+var collectionMu sync.Mutex
+
+// Variantes d'impression, dans l'ordre d'affichage, avec leur libellé.
+var cardVariants = []struct{ Key, Label string }{
+	{"normal", "Normale"},
+	{"reverse", "Reverse holo"},
+	{"holo", "Holo"},
+	{"firstEdition", "1ère édition"},
+}
+
+// États de conservation reconnus, du meilleur au pire.
+var cardConditions = []struct{ Key, Label string }{
+	{"NM", "Near Mint"},
+	{"LP", "Lightly Played"},
+	{"MP", "Moderately Played"},
+	{"HP", "Heavily Played"},
+	{"DMG", "Damaged"},
+}
+
+// Sociétés de gradation reconnues.
+var gradingCompanies = []string{"PSA", "BGS", "CGC"}
+
+// Variants indique les variantes d'impression existantes pour une carte.
+type Variants struct {
+	Normal       bool `json:"normal,omitempty"`
+	Reverse      bool `json:"reverse,omitempty"`
+	Holo         bool `json:"holo,omitempty"`
+	FirstEdition bool `json:"firstEdition,omitempty"`
+}
+
+// Has indique si la variante existe pour la carte. Sans information de l'API, toutes sont acceptées.
+func (v Variants) Has(key string) bool {
+	if v == (Variants{}) {
+		return true
+	}
+	switch key {
+	case "normal":
+		return v.Normal
+	case "reverse":
+		return v.Reverse
+	case "holo":
+		return v.Holo
+	case "firstEdition":
+		return v.FirstEdition
+	}
+	return false
+}
+
+// Grading décrit la note attribuée à une carte gradée.
+type Grading struct {
+	Company string `json:"company"`
+	Grade   string `json:"grade"`
+}
+
+// OwnedCard est un lot d'exemplaires identiques d'une carte possédée.
+type OwnedCard struct {
+	ID         string   `json:"id"`
+	CardID     string   `json:"cardId"`
+	SetID      string   `json:"setId,omitempty"`
+	LocalID    string   `json:"localId,omitempty"`
+	Quantity   int      `json:"quantity"`
+	Variant    string   `json:"variant"`
+	Condition  string   `json:"condition"`
+	Grading    *Grading `json:"grading,omitempty"`
+	AcquiredAt string   `json:"acquiredAt,omitempty"`
+	PricePaid  float64  `json:"pricePaid,omitempty"`
+	Currency   string   `json:"currency,omitempty"`
+	Note       string   `json:"note,omitempty"`
+}
+
+// Collection regroupe les cartes réellement possédées.
+type Collection struct {
+	Version int         `json:"version"`
+	Items   []OwnedCard `json:"items"`
+}
+
+func newCollection() Collection {
+	return Collection{Version: collectionSchemaVersion, Items: []OwnedCard{}}
+}
+
+// ForCard renvoie les lots possédés pour une carte donnée.
+func (c Collection) ForCard(cardID string) []OwnedCard {
+	var items []OwnedCard
+	for _, item := range c.Items {
+		if item.CardID == cardID {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Quantities renvoie le nombre d'exemplaires possédés par ID de carte.
+func (c Collection) Quantities() map[string]int {
+	quantities := make(map[string]int)
+	for _, item := range c.Items {
+		quantities[item.CardID] += item.Quantity
+	}
+	return quantities
+}
+
+func (c *Collection) find(id string) *OwnedCard {
+	for i := range c.Items {
+		if c.Items[i].ID == id {
+			return &c.Items[i]
+		}
+	}
+	return nil
+}
+
+func (c *Collection) remove(id string) bool {
+	for i := range c.Items {
+		if c.Items[i].ID == id {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func loadCollection() (Collection, error) {
+	collection := newCollection()
+
+	data, err := os.ReadFile(dataPath(collectionFile))
+	if os.IsNotExist(err) {
+		return collection, nil
+	}
+	if err != nil {
+		return collection, err
+	}
+
+	if len(data) == 0 {
+		return collection, nil
+	}
+
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return newCollection(), fmt.Errorf("fichier de collection invalide: %w", err)
+	}
+	if collection.Items == nil {
+		collection.Items = []OwnedCard{}
+	}
+
+	return collection, nil
+}
+
+func saveCollection(collection Collection) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	collection.Version = collectionSchemaVersion
+	data, err := json.Marshal(collection)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(collectionFile), data, 0644)
+}
+
+// updateCollection charge la collection, applique la modification puis la sauvegarde.
+func updateCollection(apply func(*Collection) error) error {
+	collectionMu.Lock()
+	defer collectionMu.Unlock()
+
+	collection, err := loadCollection()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(&collection); err != nil {
+		return err
+	}
+
+	return saveCollection(collection)
+}
+
+func newOwnedID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseOwnedCard lit et valide les champs d'un lot depuis un formulaire.
+func parseOwnedCard(r *http.Request, card Card) (OwnedCard, error) {
+	item := OwnedCard{
+		CardID:     card.ID,
+		SetID:      card.Set.ID,
+		LocalID:    card.LocalId,
+		Variant:    r.FormValue("variant"),
+		Condition:  r.FormValue("condition"),
+		AcquiredAt: strings.TrimSpace(r.FormValue("acquiredAt")),
+		Currency:   strings.ToUpper(strings.TrimSpace(r.FormValue("currency"))),
+		Note:       strings.TrimSpace(r.FormValue("note")),
+	}
+
+	quantity, err := strconv.Atoi(r.FormValue("quantity"))
+	if err != nil || quantity < 1 {
+		return item, fmt.Errorf("quantité invalide: %q", r.FormValue("quantity"))
+	}
+	item.Quantity = quantity
+
+	if company := r.FormValue("gradingCompany"); company != "" {
+		item.Grading = &Grading{Company: company, Grade: strings.TrimSpace(r.FormValue("grade"))}
+	}
+
+	if price := strings.TrimSpace(r.FormValue("pricePaid")); price != "" {
+		value, err := strconv.ParseFloat(strings.Replace(price, ",", ".", 1), 64)
+		if err != nil || value < 0 {
+			return item, fmt.Errorf("prix d'achat invalide: %q", price)
+		}
+		item.PricePaid = value
+		if item.Currency == "" {
+			item.Currency = "EUR"
+		}
+	}
+
+	return item, validateOwnedCard(item, card)
+}
+
+// validateOwnedCard vérifie la variante, l'état, la gradation et la date d'un lot de card,
+// qu'il vienne du formulaire ou d'un import.
+func validateOwnedCard(item OwnedCard, card Card) error {
+	validVariant := false
+	for _, v := range cardVariants {
+		if v.Key == item.Variant {
+			validVariant = true
+		}
+	}
+	if !validVariant || !card.Variants.Has(item.Variant) {
+		return fmt.Errorf("variante invalide pour cette carte: %q", item.Variant)
+	}
+
+	validCondition := false
+	for _, c := range cardConditions {
+		if c.Key == item.Condition {
+			validCondition = true
+		}
+	}
+	if !validCondition {
+		return fmt.Errorf("état invalide: %q", item.Condition)
+	}
+
+	if item.Grading != nil {
+		known := false
+		for _, c := range gradingCompanies {
+			if c == item.Grading.Company {
+				known = true
+			}
+		}
+		value, err := strconv.ParseFloat(item.Grading.Grade, 64)
+		if !known || err != nil || value < 1 || value > 10 {
+			return fmt.Errorf("gradation invalide: %s %s", item.Grading.Company, item.Grading.Grade)
+		}
+	}
+
+	if item.AcquiredAt != "" {
+		if _, err := time.Parse("2006-01-02", item.AcquiredAt); err != nil {
+			return fmt.Errorf("date d'acquisition invalide: %q", item.AcquiredAt)
+		}
+	}
+
+	return nil
+}
+
+// collectionHandler gère /collection et /collection/{add|{id}/edit|{id}/delete}.
+func collectionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/collection"), "/")
+	if rest == "" {
+		collectionPageHandler(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(rest, "/")
+	cardID := r.FormValue("cardId")
+
+	var err error
+	switch {
+	case len(parts) == 1 && parts[0] == "add":
+		if err := requireFreshData(); err != nil {
+			showError(w, "Impossible de modifier la collection", err)
+			return
+		}
+		var card Card
+		card, err = getCard(ctx, cardID)
+		if err != nil {
+			showError(w, "Impossible de récupérer la carte", err)
+			return
+		}
+		var item OwnedCard
+		item, err = parseOwnedCard(r, card)
+		if err == nil {
+			item.ID = newOwnedID()
+			err = updateCollection(func(c *Collection) error {
+				c.Items = append(c.Items, item)
+				return nil
+			})
+		}
+
+	case len(parts) == 2 && parts[1] == "edit":
+		if err := requireFreshData(); err != nil {
+			showError(w, "Impossible de modifier la collection", err)
+			return
+		}
+		var card Card
+		card, err = getCard(ctx, cardID)
+		if err != nil {
+			showError(w, "Impossible de récupérer la carte", err)
+			return
+		}
+		var item OwnedCard
+		item, err = parseOwnedCard(r, card)
+		if err == nil {
+			err = updateCollection(func(c *Collection) error {
+				existing := c.find(parts[0])
+				if existing == nil {
+					return fmt.Errorf("exemplaire introuvable: %s", parts[0])
+				}
+				item.ID = existing.ID
+				*existing = item
+				return nil
+			})
+		}
+
+	case len(parts) == 2 && parts[1] == "delete":
+		err = updateCollection(func(c *Collection) error {
+			if !c.remove(parts[0]) {
+				return fmt.Errorf("exemplaire introuvable: %s", parts[0])
+			}
+			return nil
+		})
+
+	default:
+		showError(w, "Page non trouvée", fmt.Errorf("URL invalide: %s", r.URL.Path))
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Erreur lors de la mise à jour de la collection", "err", err)
+		showError(w, "Impossible de mettre à jour la collection", err)
+		return
+	}
+
+	redirect := "/collection"
+	if cardID != "" {
+		redirect = "/card/" + cardID + "#collection"
+	}
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+func collectionPageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	collection, err := loadCollection()
+	if err != nil {
+		showError(w, "Impossible de charger la collection", err)
+		return
+	}
+
+	quantities := collection.Quantities()
+	ids := make([]string, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	total := 0
+	for _, q := range quantities {
+		total += q
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Ma Collection</h2>
+            <p class="results-count">` + strconv.Itoa(total) + ` exemplaires, ` + strconv.Itoa(len(ids)) + ` cartes différentes</p>
+        </div>`
+
+	if len(ids) == 0 {
+		html += `
+        <div class="no-favorites">
+            <p>Vous n'avez encore enregistré aucune carte possédée.</p>
+            <p>Ajoutez des exemplaires depuis la page de détail d'une carte.</p>
+        </div>`
+		writePage(w, "Ma Collection", html, "")
+		return
+	}
+
+	html += `
+        <div class="favorites-actions">
+            <a href="/favorites/export?target=collection&format=csv" class="button secondary">Exporter en CSV</a>
+            <a href="/favorites/import" class="button secondary">Importer / Exporter</a>
+            <a href="/value?target=collection" class="button secondary">Valeur</a>
+        </div>
+
+        <div class="card-grid fade-in">`
+	for _, h := range hydrateCards(ctx, ids) {
+		html += renderCardTile(h, `<p class="owned-count">× `+strconv.Itoa(quantities[h.ID])+`</p>`)
+	}
+	html += `
+        </div>`
+
+	writePage(w, "Ma Collection", html, "")
+}
+
+// renderOwnedSection génère la section d'édition de la collection sur la page d'une carte.
+func renderOwnedSection(card Card, items []OwnedCard) string {
+	html := `
+        <section id="collection" class="owned-section">
+            <h3>Ma collection</h3>`
+
+	if len(items) == 0 {
+		html += `
+            <p>Vous ne possédez pas encore cette carte.</p>`
+	}
+
+	for _, item := range items {
+		html += `
+            <form action="/collection/` + item.ID + `/edit" method="POST" class="owned-form">
+                ` + renderOwnedFields(card, item) + `
+                <button type="submit" class="button secondary">Enregistrer</button>
+                <button type="submit" formaction="/collection/` + item.ID + `/delete" class="button danger">Supprimer</button>
+            </form>`
+	}
+
+	html += `
+            <form action="/collection/add" method="POST" class="owned-form">
+                <h4>Ajouter des exemplaires</h4>
+                ` + renderOwnedFields(card, OwnedCard{Quantity: 1, Variant: defaultVariant(card), Condition: "NM"}) + `
+                <button type="submit" class="button">Ajouter</button>
+            </form>
+        </section>`
+
+	return html
+}
+
+func defaultVariant(card Card) string {
+	for _, v := range cardVariants {
+		if card.Variants.Has(v.Key) {
+			return v.Key
+		}
+	}
+	return "normal"
+}
+
+func renderOwnedFields(card Card, item OwnedCard) string {
+	html := `<input type="hidden" name="cardId" value="` + escape(card.ID) + `">
+                <label>Qté <input type="number" name="quantity" min="1" value="` + strconv.Itoa(item.Quantity) + `"></label>
+                <label>Variante <select name="variant">`
+	for _, v := range cardVariants {
+		if !card.Variants.Has(v.Key) {
+			continue
+		}
+		html += `<option value="` + v.Key + `"` + selected(v.Key == item.Variant) + `>` + v.Label + `</option>`
+	}
+	html += `</select></label>
+                <label>État <select name="condition">`
+	for _, c := range cardConditions {
+		html += `<option value="` + c.Key + `" title="` + c.Label + `"` + selected(c.Key == item.Condition) + `>` + c.Key + `</option>`
+	}
+	html += `</select></label>`
+
+	company, grade := "", ""
+	if item.Grading != nil {
+		company, grade = item.Grading.Company, item.Grading.Grade
+	}
+	html += `
+                <label>Gradation <select name="gradingCompany"><option value="">Aucune</option>`
+	for _, c := range gradingCompanies {
+		html += `<option value="` + c + `"` + selected(c == company) + `>` + c + `</option>`
+	}
+	html += `</select></label>
+                <label>Note <input type="text" name="grade" size="4" value="` + escape(grade) + `"></label>
+                <label>Acquise le <input type="date" name="acquiredAt" value="` + escape(item.AcquiredAt) + `"></label>`
+
+	price := ""
+	if item.PricePaid > 0 {
+		price = strconv.FormatFloat(item.PricePaid, 'f', 2, 64)
+	}
+	currency := item.Currency
+	if currency == "" {
+		currency = "EUR"
+	}
+	html += `
+                <label>Prix payé <input type="text" name="pricePaid" size="7" value="` + price + `"></label>
+                <label>Devise <input type="text" name="currency" size="3" value="` + escape(currency) + `"></label>
+                <label>Remarque <input type="text" name="note" value="` + escape(item.Note) + `"></label>`
+
+	return html
+}
+
+func selected(ok bool) string {
+	if ok {
+		return ` selected`
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SetCompletion résume l'avancement d'une collection (set) à partir des cartes possédées.
+// Les cartes dont le numéro dépasse le nombre officiel sont considérées comme secrètes.
+type SetCompletion struct {
+	OwnedOfficial int
+	Official      int
+	OwnedTotal    int
+	Total         int
+}
+
+// OfficialPercent renvoie le pourcentage de complétion hors cartes secrètes.
+func (c SetCompletion) OfficialPercent() int {
+	return percent(c.OwnedOfficial, c.Official)
+}
+
+// TotalPercent renvoie le pourcentage de complétion cartes secrètes comprises.
+func (c SetCompletion) TotalPercent() int {
+	return percent(c.OwnedTotal, c.Total)
+}
+
+func percent(part, whole int) int {
+	if whole <= 0 {
+		return 0
+	}
+	if part >= whole {
+		return 100
+	}
+	return part * 100 / whole
+}
+
+// isOfficialNumber indique si le numéro local fait partie de la numérotation officielle du set.
+func isOfficialNumber(localID string, official int) bool {
+	n, err := strconv.Atoi(strings.TrimLeft(localID, "0"))
+	if err != nil {
+		return false
+	}
+	return n >= 1 && n <= official
+}
+
+// ownedBySet regroupe les quantités possédées par set puis par numéro local.
+func ownedBySet(collection Collection) map[string]map[string]int {
+	owned := make(map[string]map[string]int)
+	for _, item := range collection.Items {
+		setID, localID := item.SetID, item.LocalID
+		if setID == "" || localID == "" {
+			i := strings.LastIndex(item.CardID, "-")
+			if i < 0 {
+				continue
+			}
+			setID, localID = item.CardID[:i], item.CardID[i+1:]
+		}
+		if owned[setID] == nil {
+			owned[setID] = make(map[string]int)
+		}
+		owned[setID][localID] += item.Quantity
+	}
+	return owned
+}
+
+// computeSetCompletion calcule la complétion d'un set à partir des numéros possédés.
+func computeSetCompletion(set Set, owned map[string]int) SetCompletion {
+	completion := SetCompletion{
+		Official: set.CardCount.Official,
+		Total:    set.CardCount.Total,
+	}
+	if completion.Total == 0 {
+		completion.Total = completion.Official
+	}
+
+	for localID, quantity := range owned {
+		if quantity <= 0 {
+			continue
+		}
+		completion.OwnedTotal++
+		if isOfficialNumber(localID, completion.Official) {
+			completion.OwnedOfficial++
+		}
+	}
+
+	return completion
+}
+
+// renderCompletion génère les barres de progression officielle et totale d'un set.
+func renderCompletion(c SetCompletion) string {
+	return `<div class="completion">
+                        <div class="completion-row">
+                            <span>Officielle : ` + strconv.Itoa(c.OwnedOfficial) + `/` + strconv.Itoa(c.Official) + ` (` + strconv.Itoa(c.OfficialPercent()) + ` %)</span>
+                            <div class="progress"><div class="progress-bar" style="width: ` + strconv.Itoa(c.OfficialPercent()) + `%"></div></div>
+                        </div>
+                        <div class="completion-row">
+                            <span>Avec secrètes : ` + strconv.Itoa(c.OwnedTotal) + `/` + strconv.Itoa(c.Total) + ` (` + strconv.Itoa(c.TotalPercent()) + ` %)</span>
+                            <div class="progress"><div class="progress-bar secret" style="width: ` + strconv.Itoa(c.TotalPercent()) + `%"></div></div>
+                        </div>
+                    </div>`
+}
+
+// missingCards renvoie les cartes du set non possédées, triées par numéro.
+func missingCards(cards []Card, owned map[string]int) []Card {
+	var missing []Card
+	for _, card := range cards {
+		if owned[card.LocalId] <= 0 {
+			missing = append(missing, card)
+		}
+	}
+	sortByLocalID(missing)
+	return missing
+}
+
+// sortByLocalID trie les cartes par numéro local, les numéros non numériques en dernier.
+func sortByLocalID(cards []Card) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		return localIDLess(cards[i].LocalId, cards[j].LocalId)
+	})
+}
+
+// localIDLess compare deux numéros locaux : numériquement s'ils le sont tous deux,
+// les numéros numériques avant les autres (TG01, SV001...), sinon par ordre alphabétique.
+func localIDLess(x, y string) bool {
+	a, errA := strconv.Atoi(x)
+	b, errB := strconv.Atoi(y)
+	switch {
+	case errA == nil && errB == nil:
+		return a < b
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return x < y
+}
+
+// setChecklistHandler gère /set/{id}/checklist : liste des numéros manquants,
+// imprimable en HTML ou exportable en texte (?format=txt) et CSV (?format=csv).
+func setChecklistHandler(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	set, err := fetchSet(ctx, id)
+	if err != nil {
+		showError(w, "Impossible de récupérer les détails de la collection", err)
+		return
+	}
+
+	cards, err := fetchSetCards(ctx, id, 0)
+	if err != nil {
+		showError(w, "Impossible de récupérer les cartes de la collection", err)
+		return
+	}
+
+	collection, err := loadCollection()
+	if err != nil {
+		showError(w, "Impossible de charger la collection", err)
+		return
+	}
+
+	owned := ownedBySet(collection)[id]
+	missing := missingCards(cards, owned)
+	official := set.CardCount.Official
+
+	switch r.FormValue("format") {
+	case "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+id+`-manquantes.txt"`)
+		fmt.Fprintf(w, "%s (%s) - %d cartes manquantes\n", set.Name, id, len(missing))
+		for _, card := range missing {
+			fmt.Fprintf(w, "%s/%d %s\n", card.LocalId, official, card.Name)
+		}
+		return
+
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+id+`-manquantes.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"set", "number", "name", "id", "secret"})
+		for _, card := range missing {
+			cw.Write([]string{id, card.LocalId, card.Name, card.ID, strconv.FormatBool(!isOfficialNumber(card.LocalId, official))})
+		}
+		cw.Flush()
+		return
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Cartes manquantes - ` + escape(set.Name) + `</h2>
+            <p class="results-count">` + strconv.Itoa(len(missing)) + ` cartes manquantes sur ` + strconv.Itoa(len(cards)) + `</p>
+        </div>
+
+        <div class="checklist-actions no-print">
+            <button onclick="window.print()" class="button">Imprimer</button>
+            <a href="/set/` + id + `/checklist?format=txt" class="button secondary">Exporter en texte</a>
+            <a href="/set/` + id + `/checklist?format=csv" class="button secondary">Exporter en CSV</a>
+            <a href="/set/` + id + `" class="button outline">Retour à la collection</a>
+        </div>
+
+        <ul class="checklist">`
+
+	for _, card := range missing {
+		secret := ""
+		if !isOfficialNumber(card.LocalId, official) {
+			secret = ` class="secret"`
+		}
+		html += `
+            <li` + secret + `><span class="checkbox">☐</span> <strong>` + escape(card.LocalId) + `</strong> ` + escape(card.Name) + `</li>`
+	}
+
+	html += `
+        </ul>`
+
+	writePage(w, "Manquantes "+set.Name, html, "")
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fichier de configuration lu par défaut s'il existe.
+const defaultConfigFile = "poketracker.json"
+
+// Duration est une durée lue et écrite au format de time.ParseDuration (« 20s », « 24h »).
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durée attendue sous forme de texte, par exemple \"20s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config regroupe les réglages de l'application. Chaque valeur vient, par ordre de priorité
+// croissante, des valeurs par défaut, du fichier de configuration, des variables d'environnement
+// POKETRACKER_* puis des options de la ligne de commande.
+type Config struct {
+	Port                    string   `json:"port"`
+	ReadTimeout             Duration `json:"readTimeout"`
+	WriteTimeout            Duration `json:"writeTimeout"`
+	ShutdownTimeout         Duration `json:"shutdownTimeout"`
+	DataDir                 string   `json:"dataDir"`
+	StaticDir               string   `json:"staticDir"`
+	Templates               string   `json:"templates"`
+	APIBaseURL              string   `json:"apiBaseURL"`
+	HTTPTimeout             Duration `json:"httpTimeout"`
+	Retries                 int      `json:"retries"`
+	RetryBaseDelay          Duration `json:"retryBaseDelay"`
+	RetryMaxDelay           Duration `json:"retryMaxDelay"`
+	BreakerThreshold        int      `json:"breakerThreshold"`
+	BreakerCooldown         Duration `json:"breakerCooldown"`
+	UpstreamRate            int      `json:"upstreamRate"`
+	UpstreamBackgroundRate  int      `json:"upstreamBackgroundRate"`
+	UpstreamQueueTimeout    Duration `json:"upstreamQueueTimeout"`
+	RateLimits              []string `json:"rateLimits"`
+	RateLimitMaxClients     int      `json:"rateLimitMaxClients"`
+	RateLimitAllowlist      []string `json:"rateLimitAllowlist"`
+	TrustedProxies          []string `json:"trustedProxies"`
+	USDPerEUR               float64  `json:"usdPerEUR"`
+	LogLevel                string   `json:"logLevel"`
+	LogFormat               string   `json:"logFormat"`
+	StandardRegulationMarks []string `json:"standardRegulationMarks"`
+	PricesFile              string   `json:"pricesFile"`
+	PriceSnapshotInterval   Duration `json:"priceSnapshotInterval"`
+	CatalogueIndexTTL       Duration `json:"catalogueIndexTTL"`
+	Notifiers               []string `json:"notifiers"`
+	WebhookURL              string   `json:"webhookURL"`
+	MailTo                  string   `json:"mailTo"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:                    "8080",
+		ReadTimeout:             Duration(15 * time.Second),
+		WriteTimeout:            Duration(90 * time.Second),
+		ShutdownTimeout:         Duration(30 * time.Second),
+		DataDir:                 "data",
+		StaticDir:               "static",
+		Templates:               "templates/*.html",
+		APIBaseURL:              "https://api.tcgdex.net/v2/en",
+		HTTPTimeout:             Duration(20 * time.Second),
+		Retries:                 3,
+		RetryBaseDelay:          Duration(500 * time.Millisecond),
+		RetryMaxDelay:           Duration(10 * time.Second),
+		BreakerThreshold:        5,
+		BreakerCooldown:         Duration(30 * time.Second),
+		UpstreamRate:            10,
+		UpstreamBackgroundRate:  2,
+		UpstreamQueueTimeout:    Duration(10 * time.Second),
+		RateLimits:              []string{"/=120/1m", "/static/=600/1m", "/search=20/1m", "/test-images=5/1m"},
+		RateLimitMaxClients:     10000,
+		USDPerEUR:               1.08,
+		LogLevel:                "info",
+		LogFormat:               "text",
+		StandardRegulationMarks: []string{"H", "I", "J"},
+		PriceSnapshotInterval:   Duration(24 * time.Hour),
+		CatalogueIndexTTL:       Duration(24 * time.Hour),
+		Notifiers:               []string{"log"},
+		MailTo:                  "collectionneur@localhost",
+	}
+}
+
+// appConfig est la configuration effective, remplacée au démarrage par loadConfig.
+var appConfig = defaultConfig()
+
+// dataPath renvoie le chemin d'un fichier du dossier de données.
+func dataPath(name string) string {
+	return filepath.Join(appConfig.DataDir, name)
+}
+
+// tcgdexURL renvoie l'adresse d'une ressource de l'API TCGdex.
+func tcgdexURL(format string, args ...any) string {
+	return strings.TrimRight(appConfig.APIBaseURL, "/") + fmt.Sprintf(format, args...)
+}
+
+// configField décrit un réglage : sa clé dans le fichier, sa variable d'environnement,
+// son option de ligne de commande et la façon de le lire et de l'écrire sous forme de texte.
+type configField struct {
+	Key   string
+	Env   string
+	Flag  string
+	Usage string
+	Get   func(c *Config) string
+	Set   func(c *Config, value string) error
+	// Sensitive masque la valeur dans « config print » : une adresse peut contenir un jeton.
+	Sensitive bool
+}
+
+// sensitive marque field comme secret.
+func sensitive(field configField) configField {
+	field.Sensitive = true
+	return field
+}
+
+func stringField(key, env, flagName, usage string, field func(c *Config) *string) configField {
+	return configField{
+		Key: key, Env: env, Flag: flagName, Usage: usage,
+		Get: func(c *Config) string { return *field(c) },
+		Set: func(c *Config, value string) error { *field(c) = value; return nil },
+	}
+}
+
+func listField(key, env, flagName, usage string, field func(c *Config) *[]string) configField {
+	return configField{
+		Key: key, Env: env, Flag: flagName, Usage: usage,
+		Get: func(c *Config) string { return strings.Join(*field(c), ",") },
+		Set: func(c *Config, value string) error {
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			*field(c) = items
+			return nil
+		},
+	}
+}
+
+func intField(key, env, flagName, usage string, field func(c *Config) *int) configField {
+	return configField{
+		Key: key, Env: env, Flag: flagName, Usage: usage,
+		Get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+		Set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("nombre entier attendu, reçu %q", value)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+func floatField(key, env, flagName, usage string, field func(c *Config) *float64) configField {
+	return configField{
+		Key: key, Env: env, Flag: flagName, Usage: usage,
+		Get: func(c *Config) string { return strconv.FormatFloat(*field(c), 'f', -1, 64) },
+		Set: func(c *Config, value string) error {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("nombre attendu, reçu %q", value)
+			}
+			*field(c) = f
+			return nil
+		},
+	}
+}
+
+func durationField(key, env, flagName, usage string, field func(c *Config) *Duration) configField {
+	return configField{
+		Key: key, Env: env, Flag: flagName, Usage: usage,
+		Get: func(c *Config) string { return field(c).String() },
+		Set: func(c *Config, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("durée invalide %q (exemples : 20s, 5m, 24h)", value)
+			}
+			*field(c) = Duration(d)
+			return nil
+		},
+	}
+}
+
+var configFields = []configField{
+	stringField("port", "POKETRACKER_PORT", "port", "port d'écoute HTTP",
+		func(c *Config) *string { return &c.Port }),
+	durationField("readTimeout", "POKETRACKER_READ_TIMEOUT", "read-timeout", "délai maximum de lecture d'une requête entrante",
+		func(c *Config) *Duration { return &c.ReadTimeout }),
+	durationField("writeTimeout", "POKETRACKER_WRITE_TIMEOUT", "write-timeout", "délai maximum de production d'une réponse",
+		func(c *Config) *Duration { return &c.WriteTimeout }),
+	durationField("shutdownTimeout", "POKETRACKER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "délai laissé aux requêtes et aux tâches en cours à l'arrêt",
+		func(c *Config) *Duration { return &c.ShutdownTimeout }),
+	stringField("dataDir", "POKETRACKER_DATA_DIR", "data-dir", "dossier des fichiers JSON (favoris, collection, decks...)",
+		func(c *Config) *string { return &c.DataDir }),
+	stringField("staticDir", "POKETRACKER_STATIC_DIR", "static-dir", "dossier des fichiers statiques servis sous /static/",
+		func(c *Config) *string { return &c.StaticDir }),
+	stringField("templates", "POKETRACKER_TEMPLATES", "templates", "motif des templates HTML",
+		func(c *Config) *string { return &c.Templates }),
+	stringField("apiBaseURL", "POKETRACKER_API_URL", "api-url", "adresse de base de l'API TCGdex",
+		func(c *Config) *string { return &c.APIBaseURL }),
+	durationField("httpTimeout", "POKETRACKER_HTTP_TIMEOUT", "http-timeout", "délai maximum d'une requête à l'API",
+		func(c *Config) *Duration { return &c.HTTPTimeout }),
+	intField("retries", "POKETRACKER_RETRIES", "retries", "nombre de tentatives par requête à l'API",
+		func(c *Config) *int { return &c.Retries }),
+	durationField("retryBaseDelay", "POKETRACKER_RETRY_BASE_DELAY", "retry-base-delay", "attente avant la première nouvelle tentative, doublée ensuite",
+		func(c *Config) *Duration { return &c.RetryBaseDelay }),
+	durationField("retryMaxDelay", "POKETRACKER_RETRY_MAX_DELAY", "retry-max-delay", "attente maximale entre deux tentatives, Retry-After compris",
+		func(c *Config) *Duration { return &c.RetryMaxDelay }),
+	intField("breakerThreshold", "POKETRACKER_BREAKER_THRESHOLD", "breaker-threshold", "échecs consécutifs de l'API avant de passer en mode dégradé",
+		func(c *Config) *int { return &c.BreakerThreshold }),
+	durationField("breakerCooldown", "POKETRACKER_BREAKER_COOLDOWN", "breaker-cooldown", "attente avant de vérifier à nouveau une API indisponible",
+		func(c *Config) *Duration { return &c.BreakerCooldown }),
+	intField("upstreamRate", "POKETRACKER_UPSTREAM_RATE", "upstream-rate", "requêtes par seconde vers l'API pour les visiteurs",
+		func(c *Config) *int { return &c.UpstreamRate }),
+	intField("upstreamBackgroundRate", "POKETRACKER_UPSTREAM_BACKGROUND_RATE", "upstream-background-rate", "requêtes par seconde vers l'API pour les tâches de fond",
+		func(c *Config) *int { return &c.UpstreamBackgroundRate }),
+	durationField("upstreamQueueTimeout", "POKETRACKER_UPSTREAM_QUEUE_TIMEOUT", "upstream-queue-timeout", "attente maximum d'une requête vers l'API en file",
+		func(c *Config) *Duration { return &c.UpstreamQueueTimeout }),
+	listField("rateLimits", "POKETRACKER_RATE_LIMITS", "rate-limits", "limites de requêtes par client, de la forme /route=requêtes/durée, séparées par des virgules",
+		func(c *Config) *[]string { return &c.RateLimits }),
+	intField("rateLimitMaxClients", "POKETRACKER_RATE_LIMIT_MAX_CLIENTS", "rate-limit-max-clients", "nombre maximum de compteurs de clients gardés en mémoire",
+		func(c *Config) *int { return &c.RateLimitMaxClients }),
+	listField("rateLimitAllowlist", "POKETRACKER_RATE_LIMIT_ALLOWLIST", "rate-limit-allowlist", "adresses ou plages CIDR jamais limitées, séparées par des virgules",
+		func(c *Config) *[]string { return &c.RateLimitAllowlist }),
+	listField("trustedProxies", "POKETRACKER_TRUSTED_PROXIES", "trusted-proxies", "adresses ou plages CIDR des proxys dont X-Forwarded-For est lu, séparées par des virgules",
+		func(c *Config) *[]string { return &c.TrustedProxies }),
+	floatField("usdPerEUR", "POKETRACKER_USD_PER_EUR", "usd-per-eur", "taux de change (dollars pour un euro) des montants convertis, à tenir à jour",
+		func(c *Config) *float64 { return &c.USDPerEUR }),
+	stringField("logLevel", "POKETRACKER_LOG_LEVEL", "log-level", "niveau de journalisation : debug, info, warn ou error",
+		func(c *Config) *string { return &c.LogLevel }),
+	stringField("logFormat", "POKETRACKER_LOG_FORMAT", "log-format", "format du journal : text ou json",
+		func(c *Config) *string { return &c.LogFormat }),
+	listField("standardRegulationMarks", "POKETRACKER_STANDARD_MARKS", "standard-marks", "marques de régulation légales en Standard, séparées par des virgules",
+		func(c *Config) *[]string { return &c.StandardRegulationMarks }),
+	stringField("pricesFile", "POKETRACKER_PRICES_FILE", "prices-file", "enregistrement JSON de prix à utiliser à la place de l'API",
+		func(c *Config) *string { return &c.PricesFile }),
+	durationField("priceSnapshotInterval", "POKETRACKER_PRICE_INTERVAL", "price-interval", "intervalle entre deux relevés des prix",
+		func(c *Config) *Duration { return &c.PriceSnapshotInterval }),
+	durationField("catalogueIndexTTL", "POKETRACKER_INDEX_TTL", "index-ttl", "âge au-delà duquel l'index du catalogue est reconstruit",
+		func(c *Config) *Duration { return &c.CatalogueIndexTTL }),
+	listField("notifiers", "POKETRACKER_NOTIFIERS", "notifiers", "notificateurs des alertes de prix (log, webhook, mail)",
+		func(c *Config) *[]string { return &c.Notifiers }),
+	sensitive(stringField("webhookURL", "POKETRACKER_WEBHOOK_URL", "webhook-url", "adresse du webhook des alertes",
+		func(c *Config) *string { return &c.WebhookURL })),
+	stringField("mailTo", "POKETRACKER_MAIL_TO", "mail-to", "destinataire des alertes par mail",
+		func(c *Config) *string { return &c.MailTo }),
+}
+
+// loadConfig construit la configuration effective à partir des arguments de la ligne de commande.
+// Elle renvoie aussi, pour chaque clé, l'origine de sa valeur.
+func loadConfig(args []string) (Config, map[string]string, error) {
+	cfg := defaultConfig()
+	sources := make(map[string]string, len(configFields))
+	for _, field := range configFields {
+		sources[field.Key] = "défaut"
+	}
+
+	fs := flag.NewFlagSet("poketracker", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", "", "fichier de configuration JSON (défaut : "+defaultConfigFile+" s'il existe, ou POKETRACKER_CONFIG)")
+	flagValues := make(map[string]string)
+	for _, field := range configFields {
+		field := field
+		fs.Func(field.Flag, field.Usage, func(value string) error {
+			flagValues[field.Key] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return cfg, sources, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, sources, fmt.Errorf("argument inattendu: %s", fs.Arg(0))
+	}
+
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv("POKETRACKER_CONFIG")
+	}
+	if path == "" {
+		path, required = defaultConfigFile, false
+	}
+	if err := readConfigFile(path, required, &cfg, sources); err != nil {
+		return cfg, sources, err
+	}
+
+	var errs []error
+	for _, field := range configFields {
+		if value, ok := os.LookupEnv(field.Env); ok {
+			if err := field.Set(&cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", field.Env, err))
+			}
+			sources[field.Key] = "env " + field.Env
+		}
+	}
+	for _, field := range configFields {
+		if value, ok := flagValues[field.Key]; ok {
+			if err := field.Set(&cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", field.Flag, err))
+			}
+			sources[field.Key] = "option -" + field.Flag
+		}
+	}
+	errs = append(errs, cfg.Validate())
+	return cfg, sources, errors.Join(errs...)
+}
+
+func readConfigFile(path string, required bool, cfg *Config, sources map[string]string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("lecture du fichier de configuration: %w", err)
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return fmt.Errorf("fichier de configuration %s invalide: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("fichier de configuration %s invalide: %w", path, err)
+	}
+
+	for _, field := range configFields {
+		if _, ok := present[field.Key]; ok {
+			sources[field.Key] = "fichier " + path
+		}
+	}
+	return nil
+}
+
+// Validate vérifie la cohérence de la configuration et décrit chaque valeur incorrecte.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		invalid("port", "%q n'est pas un port valide (1-65535)", c.Port)
+	}
+	if c.ReadTimeout <= 0 {
+		invalid("readTimeout", "doit être positif, reçu %s", c.ReadTimeout)
+	}
+	if c.WriteTimeout < c.HTTPTimeout {
+		invalid("writeTimeout", "doit être au moins égal à httpTimeout (%s), reçu %s", c.HTTPTimeout, c.WriteTimeout)
+	}
+	if c.ShutdownTimeout <= 0 {
+		invalid("shutdownTimeout", "doit être positif, reçu %s", c.ShutdownTimeout)
+	}
+	if c.DataDir == "" {
+		invalid("dataDir", "dossier vide")
+	}
+	if c.StaticDir == "" {
+		invalid("staticDir", "dossier vide")
+	}
+	if _, err := filepath.Match(c.Templates, ""); err != nil || c.Templates == "" {
+		invalid("templates", "motif %q invalide", c.Templates)
+	}
+	if u, err := url.Parse(c.APIBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalid("apiBaseURL", "%q n'est pas une adresse http(s) valide", c.APIBaseURL)
+	}
+	if c.HTTPTimeout <= 0 {
+		invalid("httpTimeout", "doit être positif, reçu %s", c.HTTPTimeout)
+	}
+	if c.Retries < 1 || c.Retries > 10 {
+		invalid("retries", "doit être compris entre 1 et 10, reçu %d", c.Retries)
+	}
+	if c.RetryBaseDelay <= 0 {
+		invalid("retryBaseDelay", "doit être positif, reçu %s", c.RetryBaseDelay)
+	}
+	if c.RetryMaxDelay < c.RetryBaseDelay {
+		invalid("retryMaxDelay", "doit être au moins égal à retryBaseDelay (%s), reçu %s", c.RetryBaseDelay, c.RetryMaxDelay)
+	}
+	if c.BreakerThreshold < 1 {
+		invalid("breakerThreshold", "doit être au moins 1, reçu %d", c.BreakerThreshold)
+	}
+	if c.BreakerCooldown < Duration(time.Second) {
+		invalid("breakerCooldown", "doit être d'au moins une seconde, reçu %s", c.BreakerCooldown)
+	}
+	if c.UpstreamRate < 1 {
+		invalid("upstreamRate", "doit être au moins 1, reçu %d", c.UpstreamRate)
+	}
+	if c.UpstreamBackgroundRate < 1 {
+		invalid("upstreamBackgroundRate", "doit être au moins 1, reçu %d", c.UpstreamBackgroundRate)
+	}
+	if c.UpstreamQueueTimeout <= 0 {
+		invalid("upstreamQueueTimeout", "doit être positif, reçu %s", c.UpstreamQueueTimeout)
+	}
+	if _, err := parseRateLimitRules(c.RateLimits); err != nil {
+		invalid("rateLimits", "%v", err)
+	}
+	if c.RateLimitMaxClients < 1 {
+		invalid("rateLimitMaxClients", "doit être au moins 1, reçu %d", c.RateLimitMaxClients)
+	}
+	if _, err := parseNetworks(c.RateLimitAllowlist); err != nil {
+		invalid("rateLimitAllowlist", "%v", err)
+	}
+	if _, err := parseNetworks(c.TrustedProxies); err != nil {
+		invalid("trustedProxies", "%v", err)
+	}
+	if c.USDPerEUR <= 0 {
+		invalid("usdPerEUR", "doit être positif, reçu %v", c.USDPerEUR)
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		invalid("logLevel", "%v", err)
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		invalid("logFormat", "format inconnu %q (text ou json)", c.LogFormat)
+	}
+	if len(c.StandardRegulationMarks) == 0 {
+		invalid("standardRegulationMarks", "au moins une marque est nécessaire")
+	}
+	for _, mark := range c.StandardRegulationMarks {
+		if len(mark) != 1 || mark[0] < 'A' || mark[0] > 'Z' {
+			invalid("standardRegulationMarks", "%q n'est pas une marque de régulation (une lettre majuscule)", mark)
+		}
+	}
+	if c.PriceSnapshotInterval < Duration(time.Minute) {
+		invalid("priceSnapshotInterval", "doit être d'au moins une minute, reçu %s", c.PriceSnapshotInterval)
+	}
+	if c.CatalogueIndexTTL < Duration(time.Hour) {
+		invalid("catalogueIndexTTL", "doit être d'au moins une heure, reçu %s", c.CatalogueIndexTTL)
+	}
+	for _, name := range c.Notifiers {
+		switch name {
+		case "log":
+		case "mail":
+			if !strings.Contains(c.MailTo, "@") {
+				invalid("mailTo", "%q n'est pas une adresse mail", c.MailTo)
+			}
+		case "webhook":
+			if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				invalid("webhookURL", "une adresse http(s) est nécessaire pour le notificateur webhook")
+			}
+		default:
+			invalid("notifiers", "notificateur inconnu %q (log, webhook ou mail)", name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// printConfig affiche la configuration effective et l'origine de chaque valeur.
+func printConfig(w io.Writer, cfg Config, sources map[string]string) {
+	width := 0
+	for _, field := range configFields {
+		if len(field.Key) > width {
+			width = len(field.Key)
+		}
+	}
+	for _, field := range configFields {
+		value := field.Get(&cfg)
+		if field.Sensitive {
+			value = redactURL(value)
+		}
+		fmt.Fprintf(w, "%-*s  %-32s  # %s\n", width, field.Key, value, sources[field.Key])
+	}
+}
+
+// redactURL ne garde d'une adresse que son schéma et son hôte, le chemin et les paramètres
+// portant souvent le jeton d'accès.
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return "****"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		redacted += "/****"
+	}
+	return redacted
+}
+
+// configCommand gère « poketracker config print [options] ».
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: poketracker config print [options]")
+		return 2
+	}
+
+	cfg, sources, err := loadConfig(args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Configuration invalide:\n%v\n", err)
+		return 1
+	}
+	printConfig(os.Stdout, cfg, sources)
+	return 0
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Durée de validité de l'index des codes de set.
+const setCodeIndexTTL = 12 * time.Hour
+
+// Ligne de deck : quantité, nom, code du set et numéro (« 4 Charizard ex OBF 125 »).
+var deckLineRe = regexp.MustCompile(`^(\d+)\s+(.+?)\s+([A-Z][A-Z0-9]{1,4}(?:-[A-Z0-9]+)?)\s+([A-Za-z]{0,4}\d+[a-z]?)$`)
+
+// Ligne de deck sans code de set ni numéro (« 8 Basic Fire Energy »).
+var deckLineNameRe = regexp.MustCompile(`^(\d+)\s+(.+)$`)
+
+// Symboles d'énergie utilisés par Pokémon TCG Live.
+var energySymbols = strings.NewReplacer(
+	"{G}", "Grass", "{R}", "Fire", "{W}", "Water", "{L}", "Lightning", "{P}", "Psychic",
+	"{F}", "Fighting", "{D}", "Darkness", "{M}", "Metal", "{Y}", "Fairy", "{C}", "Colorless",
+)
+
+// Codes de set historiques de PTCGO sans équivalent dans l'API.
+var setCodeAliases = map[string]string{
+	"PR-SV":  "svp",
+	"PR-SW":  "swshp",
+	"PR-SM":  "smp",
+	"PR-XY":  "xyp",
+	"PR-BLW": "bwp",
+	"SVE":    "sve",
+}
+
+// DeckLine est une ligne de liste de deck, résolue ou non vers une carte.
+type DeckLine struct {
+	Line       int
+	Raw        string
+	Section    string
+	Count      int
+	Name       string
+	SetCode    string
+	Number     string
+	CardID     string
+	Candidates []string
+	Problem    string
+}
+
+// setCodeIndex associe les codes de set (OBF, SVI...) aux IDs de set de l'API. build est la
+// construction en cours, s'il y en a une.
+var setCodeIndex = struct {
+	sync.Mutex
+	codes   map[string]string
+	builtAt time.Time
+	build   *setCodeBuild
+}{}
+
+// setCodeBuild est une construction de l'index des codes de set, attendue par les requêtes
+// qui n'ont pas encore d'index.
+type setCodeBuild struct {
+	done  chan struct{}
+	codes map[string]string
+	err   error
+}
+
+// Code renvoie le code de set utilisé dans les listes de deck.
+func (s Set) Code() string {
+	switch {
+	case s.TCGOnline != "":
+		return s.TCGOnline
+	case s.Abbr.Official != "":
+		return s.Abbr.Official
+	}
+	return strings.ToUpper(s.ID)
+}
+
+// resolveSetCode renvoie l'ID de set correspondant à un code de liste de deck, d'après l'index codes.
+func resolveSetCode(codes map[string]string, code string) (string, bool) {
+	code = strings.ToUpper(code)
+	if id, ok := setCodeAliases[code]; ok {
+		return id, true
+	}
+	id, ok := codes[code]
+	return id, ok
+}
+
+// setCodes renvoie l'index des codes de set. Un index expiré reste servi pendant sa reconstruction ;
+// sans index, la requête attend la construction, qui se poursuit en tâche de fond même si elle
+// abandonne. Le verrou n'est jamais tenu pendant les appels à l'API.
+func setCodes(ctx context.Context) (map[string]string, error) {
+	setCodeIndex.Lock()
+	codes := setCodeIndex.codes
+	build := setCodeIndex.build
+	if (codes == nil || time.Since(setCodeIndex.builtAt) > setCodeIndexTTL) && build == nil {
+		build = &setCodeBuild{done: make(chan struct{})}
+		if lifecycle.Go("index des codes de set", func(ctx context.Context) { runSetCodeBuild(ctx, build) }) {
+			setCodeIndex.build = build
+		} else {
+			build = nil
+		}
+	}
+	setCodeIndex.Unlock()
+
+	if codes != nil {
+		return codes, nil
+	}
+	if build == nil {
+		return nil, fmt.Errorf("index des codes de set indisponible: arrêt en cours")
+	}
+	select {
+	case <-build.done:
+		return build.codes, build.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runSetCodeBuild construit l'index et ne le garde que si le détail de chaque set a pu être lu :
+// sans lui, les codes OBF, SVI... manqueraient jusqu'à l'expiration de l'index. Un index incomplet
+// sert uniquement aux requêtes qui l'attendaient.
+func runSetCodeBuild(ctx context.Context, build *setCodeBuild) {
+	build.codes, build.err = buildSetCodes(ctx)
+
+	setCodeIndex.Lock()
+	setCodeIndex.build = nil
+	if build.err == nil {
+		setCodeIndex.codes = build.codes
+		setCodeIndex.builtAt = time.Now()
+	}
+	setCodeIndex.Unlock()
+	close(build.done)
+}
+
+// buildSetCodes lit le détail de chaque set. Les codes des sets lus sont renvoyés même si
+// d'autres ont échoué, avec une erreur.
+func buildSetCodes(ctx context.Context) (map[string]string, error) {
+	sets, err := fetchSets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("liste des sets: %w", err)
+	}
+
+	codes := make(map[string]string)
+	var failed []string
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan Set)
+	for w := 0; w < hydrateWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				detail, err := getSet(ctx, s.ID)
+				mu.Lock()
+				codes[strings.ToUpper(s.ID)] = s.ID
+				if err != nil {
+					failed = append(failed, s.ID)
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				if detail.TCGOnline != "" {
+					codes[strings.ToUpper(detail.TCGOnline)] = s.ID
+				}
+				if detail.Abbr.Official != "" {
+					if _, taken := codes[strings.ToUpper(detail.Abbr.Official)]; !taken {
+						codes[strings.ToUpper(detail.Abbr.Official)] = s.ID
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, s := range sets {
+		jobs <- s
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return codes, fmt.Errorf("%d sets sur %d non lus (%s...): %w", len(failed), len(sets), failed[0], firstErr)
+	}
+	slog.DebugContext(ctx, "Index des codes de set construit", "codes", len(codes))
+	return codes, nil
+}
+
+// normalizeCardName rend comparables les noms issus des listes de deck et de l'API.
+func normalizeCardName(name string) string {
+	name = energySymbols.Replace(name)
+	name = strings.TrimPrefix(strings.TrimSpace(name), "Basic ")
+	name = strings.ReplaceAll(name, "é", "e")
+	name = strings.ReplaceAll(name, "’", "'")
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// parseDeckList lit une liste de deck au format Pokémon TCG Live ou PTCGO.
+func parseDeckList(text string) []DeckLine {
+	var lines []DeckLine
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	n := 0
+	for scanner.Scan() {
+		n++
+		raw := strings.TrimSpace(scanner.Text())
+		raw = strings.TrimSpace(strings.TrimPrefix(raw, "* "))
+		if raw == "" {
+			continue
+		}
+
+		lower := strings.ToLower(strings.TrimLeft(raw, "#"))
+		switch {
+		case strings.HasPrefix(lower, "pokémon") || strings.HasPrefix(lower, "pokemon"):
+			section = "Pokemon"
+			continue
+		case strings.HasPrefix(lower, "trainer"):
+			section = "Trainer"
+			continue
+		case strings.HasPrefix(lower, "energy"):
+			section = "Energy"
+			continue
+		case strings.HasPrefix(lower, "total cards"), strings.HasPrefix(raw, "#"):
+			continue
+		}
+
+		line := DeckLine{Line: n, Raw: raw, Section: section}
+		if m := deckLineRe.FindStringSubmatch(raw); m != nil {
+			line.Count, _ = strconv.Atoi(m[1])
+			line.Name, line.SetCode, line.Number = m[2], m[3], m[4]
+		} else if m := deckLineNameRe.FindStringSubmatch(raw); m != nil {
+			line.Count, _ = strconv.Atoi(m[1])
+			line.Name = m[2]
+		} else {
+			line.Problem = "ligne illisible"
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// resolveDeckLines associe chaque ligne à un ID de carte via le code de set et le numéro local,
+// ou à défaut via le nom. Les lignes inconnues ou ambiguës sont signalées dans Problem.
+func resolveDeckLines(ctx context.Context, lines []DeckLine) {
+	setCards := make(map[string]map[string]Card)
+	// Un set en échec n'est demandé qu'une fois par liste, sans être pris pour un set vide.
+	setErrs := make(map[string]error)
+
+	// L'index des codes de set n'est demandé qu'une fois par liste.
+	var codes map[string]string
+	codesLoaded := false
+
+	for i := range lines {
+		line := &lines[i]
+		if line.Problem != "" {
+			continue
+		}
+
+		if line.SetCode != "" {
+			if !codesLoaded {
+				var err error
+				codes, err = setCodes(ctx)
+				if err != nil {
+					slog.WarnContext(ctx, "Index des codes de set incomplet", "err", err)
+				}
+				codesLoaded = true
+			}
+			setID, ok := resolveSetCode(codes, line.SetCode)
+			if !ok {
+				line.Problem = "code de set inconnu: " + line.SetCode
+				resolveByName(ctx, line)
+				continue
+			}
+
+			index, fetched := setCards[setID]
+			if !fetched && setErrs[setID] == nil {
+				cards, err := fetchSetCards(ctx, setID, 0)
+				if err != nil {
+					slog.WarnContext(ctx, "Impossible de récupérer les cartes du set", "set", setID, "err", err)
+					setErrs[setID] = err
+				} else {
+					index = make(map[string]Card)
+					for _, card := range cards {
+						index[normalizeLocalID(card.LocalId)] = card
+					}
+					setCards[setID] = index
+				}
+			}
+			if err := setErrs[setID]; err != nil {
+				line.Problem = fmt.Sprintf("set %s indisponible: %v", line.SetCode, err)
+				continue
+			}
+
+			card, ok := index[normalizeLocalID(line.Number)]
+			if !ok {
+				line.Problem = fmt.Sprintf("numéro %s absent du set %s", line.Number, line.SetCode)
+				resolveByName(ctx, line)
+				continue
+			}
+
+			line.CardID = card.ID
+			if normalizeCardName(card.Name) != normalizeCardName(line.Name) {
+				line.Problem = fmt.Sprintf("nom différent dans l'API: %s", card.Name)
+			}
+			continue
+		}
+
+		resolveByName(ctx, line)
+	}
+}
+
+// resolveByName cherche une carte portant exactement le nom de la ligne.
+// Une seule correspondance résout la ligne, plusieurs la rendent ambiguë.
+func resolveByName(ctx context.Context, line *DeckLine) {
+	cards, _, err := fetchCards(ctx, 1, 1000, map[string]string{"name": line.Name})
+	if err != nil {
+		if line.Problem == "" {
+			line.Problem = "recherche impossible: " + err.Error()
+		}
+		return
+	}
+
+	want := normalizeCardName(line.Name)
+	var matches []string
+	for _, card := range cards {
+		if normalizeCardName(card.Name) == want {
+			matches = append(matches, card.ID)
+		}
+	}
+	sort.Strings(matches)
+
+	switch {
+	case len(matches) == 0:
+		if line.Problem == "" {
+			line.Problem = "carte inconnue"
+		}
+	case len(matches) == 1:
+		line.CardID = matches[0]
+	default:
+		line.Candidates = matches
+		line.Problem = fmt.Sprintf("ambiguë: %d cartes portent ce nom", len(matches))
+		if line.SetCode != "" {
+			line.Problem = "code ou numéro inconnu, " + line.Problem
+		}
+	}
+}
+
+// cardSetCode renvoie le code de set d'une carte tel qu'il apparaît dans les listes de deck.
+func cardSetCode(ctx context.Context, card Card) string {
+	if set, err := getSet(ctx, card.Set.ID); err == nil {
+		return set.Code()
+	}
+	return strings.ToUpper(card.Set.ID)
+}
+
+// formatDeckList génère la liste d'un deck au format Pokémon TCG Live.
+func formatDeckList(ctx context.Context, deck Deck, cards map[string]HydratedCard) string {
+	var b strings.Builder
+
+	for _, category := range deckCategories {
+		var entries []DeckEntry
+		count := 0
+		for _, entry := range deck.Cards {
+			if cards[entry.CardID].Card.Category == category.Key {
+				entries = append(entries, entry)
+				count += entry.Count
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		header := category.Key
+		if header == "Pokemon" {
+			header = "Pokémon"
+		}
+		fmt.Fprintf(&b, "%s: %d\n", header, count)
+
+		for _, entry := range entries {
+			card := cards[entry.CardID].Card
+			fmt.Fprintf(&b, "%d %s %s %s\n", entry.Count, card.Name, cardSetCode(ctx, card), card.LocalId)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "Total Cards: %d\n", deck.Total())
+	return b.String()
+}
+
+// deckExportHandler gère /decks/{slug}/export.
+func deckExportHandler(w http.ResponseWriter, r *http.Request, slug string) {
+	ctx := r.Context()
+	decks, err := loadDecks()
+	if err != nil {
+		showError(w, "Impossible de charger les decks", err)
+		return
+	}
+
+	deck := decks.Deck(slug)
+	if deck == nil {
+		showError(w, "Deck introuvable", fmt.Errorf("aucun deck %s", slug))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if r.FormValue("download") != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+slug+`.txt"`)
+	}
+	w.Write([]byte(formatDeckList(ctx, *deck, hydrateDeck(ctx, *deck))))
+}
+
+// deckImportHandler gère /decks/{slug}/import : analyse d'une liste puis application.
+func deckImportHandler(w http.ResponseWriter, r *http.Request, slug string) {
+	ctx := r.Context()
+	replace := r.FormValue("mode") == "replace"
+
+	if r.FormValue("confirm") != "" {
+		entries := make(map[string]int)
+		var order []string
+		for _, field := range strings.Split(r.FormValue("entries"), ",") {
+			id, count, ok := strings.Cut(field, ":")
+			n, err := strconv.Atoi(count)
+			if !ok || err != nil || id == "" {
+				continue
+			}
+			if _, seen := entries[id]; !seen {
+				order = append(order, id)
+			}
+			entries[id] += n
+		}
+
+		err := updateDecks(func(d *Decks) error {
+			deck := d.Deck(slug)
+			if deck == nil {
+				return fmt.Errorf("aucun deck %s", slug)
+			}
+			if replace {
+				deck.Cards = []DeckEntry{}
+			}
+			for _, id := range order {
+				deck.SetCount(id, deck.Count(id)+entries[id])
+			}
+			return nil
+		})
+		if err != nil {
+			showError(w, "Impossible d'importer la liste", err)
+			return
+		}
+
+		http.Redirect(w, r, "/decks/"+slug, http.StatusSeeOther)
+		return
+	}
+
+	lines := parseDeckList(r.FormValue("list"))
+	resolveDeckLines(ctx, lines)
+
+	var resolved, problems []DeckLine
+	var entries []string
+	total := 0
+	for _, line := range lines {
+		if line.CardID != "" {
+			resolved = append(resolved, line)
+			entries = append(entries, line.CardID+":"+strconv.Itoa(line.Count))
+			total += line.Count
+		}
+		if line.Problem != "" {
+			problems = append(problems, line)
+		}
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Import de liste</h2>
+            <p class="results-count">` + strconv.Itoa(len(resolved)) + ` lignes résolues (` + strconv.Itoa(total) + ` cartes), ` + strconv.Itoa(len(problems)) + ` à vérifier</p>
+        </div>`
+
+	if len(problems) > 0 {
+		html += `
+        <section class="diff-section unresolved">
+            <h3>Lignes à vérifier</h3>
+            <ul>`
+		for _, line := range problems {
+			html += `
+                <li>Ligne ` + strconv.Itoa(line.Line) + ` « ` + escape(line.Raw) + ` » : ` + escape(line.Problem)
+			if line.CardID != "" {
+				html += ` (retenue : <a href="/card/` + escape(line.CardID) + `">` + escape(line.CardID) + `</a>)`
+			}
+			if len(line.Candidates) > 0 {
+				html += ` — candidates : ` + escape(strings.Join(line.Candidates, ", "))
+			}
+			html += `</li>`
+		}
+		html += `
+            </ul>
+        </section>`
+	}
+
+	html += `
+        <section class="diff-section added">
+            <h3>Cartes reconnues</h3>
+            <ul>`
+	for _, line := range resolved {
+		html += `
+                <li>` + strconv.Itoa(line.Count) + ` × ` + escape(line.Name) + ` → ` + escape(line.CardID) + `</li>`
+	}
+
+	mode := "merge"
+	if replace {
+		mode = "replace"
+	}
+	html += `
+            </ul>
+        </section>
+
+        <form action="/decks/` + slug + `/import" method="POST" class="list-form">
+            <input type="hidden" name="confirm" value="1">
+            <input type="hidden" name="mode" value="` + mode + `">
+            <input type="hidden" name="entries" value="` + escape(strings.Join(entries, ",")) + `">
+            <button type="submit" class="button">Appliquer au deck</button>
+            <a href="/decks/` + slug + `" class="button outline">Annuler</a>
+        </form>`
+
+	writePage(w, "Import de liste", html, "")
+}
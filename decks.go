@@ -0,0 +1,772 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version actuelle du schéma de data/decks.json.
+const decksSchemaVersion = 1
+
+const decksFile = "decks.json"
+
+// decksMu sérialise les cycles lecture-modification-écriture du fichier de decks.
+var decksMu sync.Mutex
+
+// Règles de construction d'un deck.
+const (
+	deckSize      = 60
+	maxCopies     = 4
+	maxAceSpec    = 1
+	maxRadiant    = 1
+	maxDeckCopies = 60
+)
+
+// Formats de jeu reconnus, avec leur libellé.
+var deckFormats = []struct{ Key, Label string }{
+	{"standard", "Standard"},
+	{"expanded", "Expanded"},
+	{"unlimited", "Illimité"},
+}
+
+// Catégories de cartes dans l'ordre d'affichage d'un deck.
+var deckCategories = []struct{ Key, Label string }{
+	{"Pokemon", "Pokémon"},
+	{"Trainer", "Dresseur"},
+	{"Energy", "Énergie"},
+}
+
+// DeckEntry associe une carte à son nombre d'exemplaires dans un deck.
+type DeckEntry struct {
+	CardID string `json:"cardId"`
+	Count  int    `json:"count"`
+}
+
+// Deck est une liste de 60 cartes destinée à un format de jeu.
+type Deck struct {
+	Slug      string      `json:"slug"`
+	Name      string      `json:"name"`
+	Format    string      `json:"format"`
+	Cards     []DeckEntry `json:"cards"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// Decks regroupe l'ensemble des decks enregistrés.
+type Decks struct {
+	Version int    `json:"version"`
+	Decks   []Deck `json:"decks"`
+}
+
+// DeckIssue est une erreur de validation d'un deck. CardID est vide pour les règles globales.
+type DeckIssue struct {
+	Rule    string
+	Message string
+	CardID  string
+}
+
+func newDecks() Decks {
+	return Decks{Version: decksSchemaVersion, Decks: []Deck{}}
+}
+
+// Deck renvoie le deck correspondant au slug, ou nil s'il n'existe pas.
+func (d *Decks) Deck(slug string) *Deck {
+	for i := range d.Decks {
+		if d.Decks[i].Slug == slug {
+			return &d.Decks[i]
+		}
+	}
+	return nil
+}
+
+// CreateDeck ajoute un deck vide avec un slug unique dérivé de son nom.
+func (d *Decks) CreateDeck(name, format string) *Deck {
+	base := slugify(name)
+	if base == "" {
+		base = "deck"
+	}
+
+	slug := base
+	for i := 2; d.Deck(slug) != nil; i++ {
+		slug = base + "-" + strconv.Itoa(i)
+	}
+
+	d.Decks = append(d.Decks, Deck{
+		Slug:      slug,
+		Name:      name,
+		Format:    format,
+		Cards:     []DeckEntry{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	return &d.Decks[len(d.Decks)-1]
+}
+
+// DeleteDeck supprime un deck.
+func (d *Decks) DeleteDeck(slug string) bool {
+	for i := range d.Decks {
+		if d.Decks[i].Slug == slug {
+			d.Decks = append(d.Decks[:i], d.Decks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Count renvoie le nombre d'exemplaires d'une carte dans le deck.
+func (d *Deck) Count(cardID string) int {
+	for _, entry := range d.Cards {
+		if entry.CardID == cardID {
+			return entry.Count
+		}
+	}
+	return 0
+}
+
+// SetCount fixe le nombre d'exemplaires d'une carte ; zéro retire la carte du deck.
+func (d *Deck) SetCount(cardID string, count int) {
+	d.UpdatedAt = time.Now()
+	for i, entry := range d.Cards {
+		if entry.CardID == cardID {
+			if count <= 0 {
+				d.Cards = append(d.Cards[:i], d.Cards[i+1:]...)
+			} else {
+				d.Cards[i].Count = count
+			}
+			return
+		}
+	}
+	if count > 0 {
+		d.Cards = append(d.Cards, DeckEntry{CardID: cardID, Count: count})
+	}
+}
+
+// Total renvoie le nombre total de cartes du deck.
+func (d *Deck) Total() int {
+	total := 0
+	for _, entry := range d.Cards {
+		total += entry.Count
+	}
+	return total
+}
+
+// IDs renvoie les IDs des cartes du deck.
+func (d *Deck) IDs() []string {
+	ids := make([]string, len(d.Cards))
+	for i, entry := range d.Cards {
+		ids[i] = entry.CardID
+	}
+	return ids
+}
+
+func loadDecks() (Decks, error) {
+	decks := newDecks()
+
+	data, err := os.ReadFile(dataPath(decksFile))
+	if os.IsNotExist(err) {
+		return decks, nil
+	}
+	if err != nil {
+		return decks, err
+	}
+
+	if len(data) == 0 {
+		return decks, nil
+	}
+
+	if err := json.Unmarshal(data, &decks); err != nil {
+		return newDecks(), fmt.Errorf("fichier de decks invalide: %w", err)
+	}
+	if decks.Decks == nil {
+		decks.Decks = []Deck{}
+	}
+
+	return decks, nil
+}
+
+func saveDecks(decks Decks) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	decks.Version = decksSchemaVersion
+	data, err := json.Marshal(decks)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(decksFile), data, 0644)
+}
+
+// updateDecks charge les decks, applique la modification puis les sauvegarde.
+func updateDecks(apply func(*Decks) error) error {
+	decksMu.Lock()
+	defer decksMu.Unlock()
+
+	decks, err := loadDecks()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(&decks); err != nil {
+		return err
+	}
+
+	return saveDecks(decks)
+}
+
+func isBasicEnergy(card Card) bool {
+	if card.Category != "Energy" {
+		return false
+	}
+	return card.EnergyType == "Basic" || strings.HasPrefix(card.Name, "Basic ")
+}
+
+func isBasicPokemon(card Card) bool {
+	return card.Category == "Pokemon" && card.Stage == "Basic"
+}
+
+func isAceSpec(card Card) bool {
+	return strings.Contains(strings.ToUpper(card.Rarity), "ACE SPEC")
+}
+
+func isRadiant(card Card) bool {
+	return card.Category == "Pokemon" && strings.HasPrefix(card.Name, "Radiant ")
+}
+
+// legalIn indique si une carte est jouable dans le format donné.
+// Le Standard repose sur la marque de régulation, l'Expanded sur la légalité de la carte ou de son set.
+func legalIn(ctx context.Context, card Card, format string) bool {
+	return cardLegalIn(card, format, func() Legal { return setLegal(ctx, card.Set.ID) })
+}
+
+// cardLegalIn applique les règles de légalité ; la légalité du set n'est demandée
+// que lorsque la carte seule ne suffit pas à trancher.
+func cardLegalIn(card Card, format string, set func() Legal) bool {
+	if isBasicEnergy(card) {
+		return true
+	}
+
+	switch format {
+	case "standard":
+		if card.RegulationMark != "" {
+			for _, mark := range appConfig.StandardRegulationMarks {
+				if strings.EqualFold(mark, card.RegulationMark) {
+					return true
+				}
+			}
+			return false
+		}
+		return card.Legal.Standard || set().Standard
+	case "expanded":
+		return card.Legal.Expanded || set().Expanded
+	}
+
+	return true
+}
+
+func setLegal(ctx context.Context, setID string) Legal {
+	if setID == "" {
+		return Legal{}
+	}
+	set, err := getSet(ctx, setID)
+	if err != nil {
+		slog.WarnContext(ctx, "Impossible de vérifier la légalité du set", "set", setID, "err", err)
+		return Legal{}
+	}
+	return set.Legal
+}
+
+// validateDeck vérifie les règles de construction et de légalité du deck.
+func validateDeck(ctx context.Context, deck Deck, cards map[string]HydratedCard) []DeckIssue {
+	var issues []DeckIssue
+
+	if total := deck.Total(); total != deckSize {
+		issues = append(issues, DeckIssue{
+			Rule:    "size",
+			Message: fmt.Sprintf("Le deck doit contenir exactement %d cartes (actuellement %d).", deckSize, total),
+		})
+	}
+
+	copiesByName := make(map[string]int)
+	basics, aceSpecs, radiants := 0, 0, 0
+
+	for _, entry := range deck.Cards {
+		h, ok := cards[entry.CardID]
+		if !ok || h.Unknown {
+			issues = append(issues, DeckIssue{Rule: "unknown", Message: "Carte inconnue du catalogue.", CardID: entry.CardID})
+			continue
+		}
+		if h.Err != nil {
+			issues = append(issues, DeckIssue{Rule: "unavailable", Message: "Données de la carte indisponibles, validation incomplète.", CardID: entry.CardID})
+			continue
+		}
+
+		card := h.Card
+		if !isBasicEnergy(card) {
+			copiesByName[card.Name] += entry.Count
+		}
+		if isBasicPokemon(card) {
+			basics += entry.Count
+		}
+		if isAceSpec(card) {
+			aceSpecs += entry.Count
+		}
+		if isRadiant(card) {
+			radiants += entry.Count
+		}
+
+		if !legalIn(ctx, card, deck.Format) {
+			issues = append(issues, DeckIssue{
+				Rule:    "legality",
+				Message: fmt.Sprintf("Carte non légale en format %s (marque %q).", deck.Format, card.RegulationMark),
+				CardID:  entry.CardID,
+			})
+		}
+	}
+
+	for _, entry := range deck.Cards {
+		card := cards[entry.CardID].Card
+		if card.Name != "" && copiesByName[card.Name] > maxCopies {
+			issues = append(issues, DeckIssue{
+				Rule:    "copies",
+				Message: fmt.Sprintf("%d exemplaires de « %s » (maximum %d par nom).", copiesByName[card.Name], card.Name, maxCopies),
+				CardID:  entry.CardID,
+			})
+		}
+	}
+
+	if basics == 0 {
+		issues = append(issues, DeckIssue{Rule: "basic", Message: "Le deck doit contenir au moins un Pokémon de base."})
+	}
+	if aceSpecs > maxAceSpec {
+		issues = append(issues, DeckIssue{Rule: "acespec", Message: fmt.Sprintf("%d cartes ACE SPEC (maximum %d).", aceSpecs, maxAceSpec)})
+	}
+	if radiants > maxRadiant {
+		issues = append(issues, DeckIssue{Rule: "radiant", Message: fmt.Sprintf("%d Pokémon Radieux (maximum %d).", radiants, maxRadiant)})
+	}
+
+	return issues
+}
+
+// hydrateDeck récupère les cartes d'un deck, indexées par ID.
+func hydrateDeck(ctx context.Context, deck Deck) map[string]HydratedCard {
+	cards := make(map[string]HydratedCard)
+	for _, h := range hydrateCards(ctx, deck.IDs()) {
+		cards[h.ID] = h
+	}
+	return cards
+}
+
+func validFormat(format string) bool {
+	for _, f := range deckFormats {
+		if f.Key == format {
+			return true
+		}
+	}
+	return false
+}
+
+func formatLabel(format string) string {
+	for _, f := range deckFormats {
+		if f.Key == format {
+			return f.Label
+		}
+	}
+	return format
+}
+
+func formatOptions(current string) string {
+	html := ""
+	for _, f := range deckFormats {
+		html += `<option value="` + f.Key + `"` + selected(f.Key == current) + `>` + f.Label + `</option>`
+	}
+	return html
+}
+
+func decksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		name := strings.TrimSpace(r.FormValue("name"))
+		format := r.FormValue("format")
+		if name == "" || !validFormat(format) {
+			http.Error(w, "Nom et format de deck requis", http.StatusBadRequest)
+			return
+		}
+
+		var slug string
+		err := updateDecks(func(d *Decks) error {
+			slug = d.CreateDeck(name, format).Slug
+			return nil
+		})
+		if err != nil {
+			showError(w, "Impossible de créer le deck", err)
+			return
+		}
+
+		http.Redirect(w, r, "/decks/"+slug, http.StatusSeeOther)
+		return
+	}
+
+	decks, err := loadDecks()
+	if err != nil {
+		showError(w, "Impossible de charger les decks", err)
+		return
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Mes Decks</h2>
+            <p>Construisez des decks de 60 cartes et vérifiez leur légalité.</p>
+        </div>
+
+        <div class="list-grid">`
+
+	for _, deck := range decks.Decks {
+		html += `
+            <a class="list-tile" href="/decks/` + deck.Slug + `">
+                <h3>` + escape(deck.Name) + `</h3>
+                <p>` + formatLabel(deck.Format) + ` — ` + strconv.Itoa(deck.Total()) + `/` + strconv.Itoa(deckSize) + ` cartes</p>
+            </a>`
+	}
+
+	html += `
+        </div>
+
+        <form action="/decks" method="POST" class="list-form">
+            <h3>Nouveau deck</h3>
+            <input type="text" name="name" placeholder="Nom du deck" required>
+            <select name="format">` + formatOptions("standard") + `</select>
+            <button type="submit" class="button">Créer</button>
+        </form>`
+
+	writePage(w, "Mes Decks", html, "")
+}
+
+// deckHandler gère /decks/{slug} et ses actions /decks/{slug}/{cards|edit|delete}.
+func deckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	slug, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/decks/"), "/"), "/")
+	if slug == "" {
+		http.Redirect(w, r, "/decks", http.StatusSeeOther)
+		return
+	}
+
+	if action == "export" {
+		deckExportHandler(w, r, slug)
+		return
+	}
+
+	if action != "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+			return
+		}
+		deckActionHandler(w, r, slug, action)
+		return
+	}
+
+	decks, err := loadDecks()
+	if err != nil {
+		showError(w, "Impossible de charger les decks", err)
+		return
+	}
+
+	deck := decks.Deck(slug)
+	if deck == nil {
+		showError(w, "Deck introuvable", fmt.Errorf("aucun deck %s", slug))
+		return
+	}
+
+	cards := hydrateDeck(ctx, *deck)
+	issues := validateDeck(ctx, *deck, cards)
+
+	cardIssues := make(map[string][]DeckIssue)
+	var globalIssues []DeckIssue
+	for _, issue := range issues {
+		if issue.CardID == "" {
+			globalIssues = append(globalIssues, issue)
+		} else {
+			cardIssues[issue.CardID] = append(cardIssues[issue.CardID], issue)
+		}
+	}
+
+	status := `<span class="deck-status valid">Deck valide</span>`
+	if len(issues) > 0 {
+		status = `<span class="deck-status invalid">` + strconv.Itoa(len(issues)) + ` problème(s)</span>`
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>` + escape(deck.Name) + `</h2>
+            <p class="results-count">` + formatLabel(deck.Format) + ` — ` + strconv.Itoa(deck.Total()) + `/` + strconv.Itoa(deckSize) + ` cartes ` + status + `</p>
+        </div>`
+
+	if len(globalIssues) > 0 {
+		html += `
+        <div class="error-message deck-issues">
+            <ul>`
+		for _, issue := range globalIssues {
+			html += `
+                <li>` + escape(issue.Message) + `</li>`
+		}
+		html += `
+            </ul>
+        </div>`
+	}
+
+	for _, category := range deckCategories {
+		var entries []DeckEntry
+		count := 0
+		for _, entry := range deck.Cards {
+			if cards[entry.CardID].Card.Category == category.Key {
+				entries = append(entries, entry)
+				count += entry.Count
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return cards[entries[i].CardID].Card.Name < cards[entries[j].CardID].Card.Name
+		})
+
+		html += `
+        <section class="deck-section">
+            <h3>` + category.Label + ` (` + strconv.Itoa(count) + `)</h3>
+            <table class="deck-table">`
+		for _, entry := range entries {
+			html += renderDeckRow(deck.Slug, entry, cards[entry.CardID], cardIssues[entry.CardID])
+		}
+		html += `
+            </table>
+        </section>`
+	}
+
+	var others []DeckEntry
+	for _, entry := range deck.Cards {
+		known := false
+		for _, category := range deckCategories {
+			if cards[entry.CardID].Card.Category == category.Key {
+				known = true
+			}
+		}
+		if !known {
+			others = append(others, entry)
+		}
+	}
+	if len(others) > 0 {
+		html += `
+        <section class="deck-section">
+            <h3>Autres</h3>
+            <table class="deck-table">`
+		for _, entry := range others {
+			html += renderDeckRow(deck.Slug, entry, cards[entry.CardID], cardIssues[entry.CardID])
+		}
+		html += `
+            </table>
+        </section>`
+	}
+
+	html += `
+        <form action="/decks/` + deck.Slug + `/cards" method="POST" class="list-form">
+            <h3>Ajouter une carte</h3>
+            <input type="text" name="cardId" placeholder="ID de la carte (ex: sv03-125)" required>
+            <input type="number" name="delta" min="1" max="` + strconv.Itoa(maxDeckCopies) + `" value="1">
+            <button type="submit" class="button">Ajouter</button>
+        </form>
+
+        <form action="/decks/` + deck.Slug + `/import" method="POST" class="list-form import-form">
+            <h3>Importer une liste (Pokémon TCG Live / PTCGO)</h3>
+            <textarea name="list" rows="8" placeholder="Pokémon: 4&#10;4 Charizard ex OBF 125&#10;Trainer: 4&#10;4 Rare Candy SVI 191"></textarea>
+            <select name="mode">
+                <option value="merge">Ajouter au deck</option>
+                <option value="replace">Remplacer le deck</option>
+            </select>
+            <button type="submit" class="button">Analyser</button>
+            <a href="/decks/` + deck.Slug + `/export" class="button secondary">Exporter la liste</a>
+            <a href="/analytics?deck=` + deck.Slug + `" class="button secondary">Statistiques</a>
+            <a href="/build?deck=` + deck.Slug + `" class="button secondary">Ce qu'il me manque</a>
+        </form>
+
+        <form action="/decks/` + deck.Slug + `/edit" method="POST" class="list-form">
+            <h3>Modifier le deck</h3>
+            <input type="text" name="name" value="` + escape(deck.Name) + `" required>
+            <select name="format">` + formatOptions(deck.Format) + `</select>
+            <button type="submit" class="button">Enregistrer</button>
+        </form>
+
+        <form action="/decks/` + deck.Slug + `/delete" method="POST" class="list-form" onsubmit="return confirm('Supprimer ce deck ?');">
+            <button type="submit" class="button danger">Supprimer le deck</button>
+        </form>`
+
+	writePage(w, deck.Name, html, "")
+}
+
+func renderDeckRow(slug string, entry DeckEntry, h HydratedCard, issues []DeckIssue) string {
+	name := escape(entry.CardID)
+	details := ""
+	if h.Card.Name != "" {
+		name = `<a href="/card/` + escape(entry.CardID) + `">` + escape(h.Card.Name) + `</a>`
+		details = escape(h.Card.Set.Name) + ` ` + escape(h.Card.LocalId)
+		if h.Card.RegulationMark != "" {
+			details += ` <span class="regulation-mark">` + escape(h.Card.RegulationMark) + `</span>`
+		}
+	}
+
+	class := ""
+	if len(issues) > 0 {
+		class = ` class="has-issue"`
+	}
+
+	html := `
+                <tr` + class + `>
+                    <td>
+                        <form action="/decks/` + slug + `/cards" method="POST" class="deck-count-form">
+                            <input type="hidden" name="cardId" value="` + escape(entry.CardID) + `">
+                            <input type="number" name="count" min="0" max="` + strconv.Itoa(maxDeckCopies) + `" value="` + strconv.Itoa(entry.Count) + `">
+                            <button type="submit">OK</button>
+                        </form>
+                    </td>
+                    <td>` + name + `</td>
+                    <td>` + details + `</td>
+                    <td>`
+	for _, issue := range issues {
+		html += `<p class="deck-issue">` + escape(issue.Message) + `</p>`
+	}
+	return html + `</td>
+                </tr>`
+}
+
+func deckActionHandler(w http.ResponseWriter, r *http.Request, slug, action string) {
+	ctx := r.Context()
+	redirect := "/decks/" + slug
+
+	var err error
+	switch action {
+	case "cards":
+		cardID := strings.TrimSpace(r.FormValue("cardId"))
+		if cardID == "" {
+			http.Error(w, "ID de carte requis", http.StatusBadRequest)
+			return
+		}
+
+		delta, deltaErr := strconv.Atoi(r.FormValue("delta"))
+		count, countErr := strconv.Atoi(r.FormValue("count"))
+		if deltaErr != nil && countErr != nil {
+			http.Error(w, "Nombre d'exemplaires invalide", http.StatusBadRequest)
+			return
+		}
+
+		// Seul un ajout d'exemplaires vérifie la carte auprès de l'API : un retrait reste possible
+		// en mode dégradé, et pour une carte que TCGdex ne connaît plus.
+		increase := deltaErr == nil && delta > 0
+		if deltaErr != nil {
+			decks, loadErr := loadDecks()
+			if loadErr != nil {
+				showError(w, "Impossible de charger les decks", loadErr)
+				return
+			}
+			if deck := decks.Deck(slug); deck != nil {
+				increase = count > deck.Count(cardID)
+			}
+		}
+		if increase {
+			if err := requireFreshData(); err != nil {
+				showError(w, "Impossible d'ajouter la carte au deck", err)
+				return
+			}
+			if _, err := getCard(ctx, cardID); err != nil {
+				showError(w, "Impossible d'ajouter la carte au deck", err)
+				return
+			}
+		}
+
+		err = updateDecks(func(d *Decks) error {
+			deck := d.Deck(slug)
+			if deck == nil {
+				return fmt.Errorf("aucun deck %s", slug)
+			}
+			if deltaErr == nil {
+				count = deck.Count(cardID) + delta
+			}
+			if count > maxDeckCopies {
+				count = maxDeckCopies
+			}
+			deck.SetCount(cardID, count)
+			return nil
+		})
+		if r.FormValue("back") != "" {
+			redirect = "/card/" + cardID
+		}
+
+	case "edit":
+		name := strings.TrimSpace(r.FormValue("name"))
+		format := r.FormValue("format")
+		err = updateDecks(func(d *Decks) error {
+			deck := d.Deck(slug)
+			if deck == nil {
+				return fmt.Errorf("aucun deck %s", slug)
+			}
+			if name != "" {
+				deck.Name = name
+			}
+			if validFormat(format) {
+				deck.Format = format
+			}
+			deck.UpdatedAt = time.Now()
+			return nil
+		})
+
+	case "import":
+		deckImportHandler(w, r, slug)
+		return
+
+	case "delete":
+		redirect = "/decks"
+		err = updateDecks(func(d *Decks) error {
+			if !d.DeleteDeck(slug) {
+				return fmt.Errorf("aucun deck %s", slug)
+			}
+			return nil
+		})
+
+	default:
+		showError(w, "Page non trouvée", fmt.Errorf("action de deck inconnue: %s", action))
+		return
+	}
+
+	if err != nil {
+		showError(w, "Impossible de modifier le deck", err)
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// renderDeckPicker génère le formulaire d'ajout d'une carte à un deck depuis sa page de détail.
+func renderDeckPicker(cardID string) string {
+	decks, err := loadDecks()
+	if err != nil || len(decks.Decks) == 0 {
+		return ""
+	}
+
+	html := `<form method="POST" class="deck-picker" onsubmit="this.action = '/decks/' + this.deck.value + '/cards';">
+                    <input type="hidden" name="cardId" value="` + escape(cardID) + `">
+                    <input type="hidden" name="delta" value="1">
+                    <input type="hidden" name="back" value="1">
+                    <select name="deck">`
+	for _, deck := range decks.Decks {
+		html += `<option value="` + deck.Slug + `">` + escape(deck.Name) + ` (` + strconv.Itoa(deck.Count(cardID)) + `)</option>`
+	}
+	return html + `</select>
+                    <button type="submit" class="button secondary">+1 au deck</button>
+                </form>`
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Nombre maximum d'appels à l'API lancés en parallèle par une même page.
+const pageFetchWorkers = 4
+
+// fetchGroup lance en parallèle les récupérations indépendantes d'une page, au plus limit à la
+// fois, et conserve l'erreur de chacune sous son nom pour que la page signale chaque échec à part.
+type fetchGroup struct {
+	ctx  context.Context
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs map[string]error
+	// names garde l'ordre des appels à Go, pour que Wait réunisse les erreurs dans cet ordre.
+	names []string
+}
+
+func newFetchGroup(ctx context.Context, limit int) *fetchGroup {
+	return &fetchGroup{ctx: ctx, sem: make(chan struct{}, limit), errs: make(map[string]error)}
+}
+
+// Go lance fn dès qu'une place se libère. Si ctx est annulé avant, fn n'est pas appelée
+// et l'annulation est enregistrée comme son erreur.
+func (g *fetchGroup) Go(name string, fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	g.names = append(g.names, name)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.setErr(name, g.ctx.Err())
+			return
+		}
+		defer func() { <-g.sem }()
+
+		if err := fn(g.ctx); err != nil {
+			g.setErr(name, err)
+		}
+	}()
+}
+
+func (g *fetchGroup) setErr(name string, err error) {
+	g.mu.Lock()
+	g.errs[name] = err
+	g.mu.Unlock()
+}
+
+// Wait attend la fin de toutes les récupérations et renvoie leurs erreurs réunies dans l'ordre
+// des appels à Go, ou nil.
+func (g *fetchGroup) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var errs []error
+	for _, name := range g.names {
+		if err, ok := g.errs[name]; ok {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Err renvoie l'erreur de la récupération name, à appeler après Wait.
+func (g *fetchGroup) Err(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.errs[name]
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Version actuelle du schéma de data/favorites.json.
+// Version 0 : instantanés complets des cartes ; version 1 : références par ID ;
+// version 2 : plusieurs listes nommées, dont la liste des favoris.
+const favoritesSchemaVersion = 2
+
+const favoritesFile = "favorites.json"
+
+// Slug de la liste historique des favoris, utilisée par /favorites et /api/favorite/*.
+const favoritesListSlug = "favorites"
+
+// errFavoritesCorrupt signale un fichier de favoris illisible, laissé tel quel sur le disque.
+var errFavoritesCorrupt = errors.New("fichier de favoris illisible")
+
+// favoritesMu sérialise les cycles lecture-modification-écriture du fichier de favoris.
+var favoritesMu sync.Mutex
+
+// Favorites regroupe l'ensemble des listes de cartes de l'utilisateur.
+type Favorites struct {
+	Version int        `json:"version"`
+	Lists   []CardList `json:"lists"`
+}
+
+// FavoriteEntry référence une carte d'une liste par son ID, accompagnée des métadonnées utilisateur.
+type FavoriteEntry struct {
+	ID      string    `json:"id"`
+	AddedAt time.Time `json:"addedAt"`
+	Note    string    `json:"note,omitempty"`
+}
+
+func newFavorites() Favorites {
+	favorites := Favorites{Version: favoritesSchemaVersion}
+	for _, list := range defaultLists {
+		list.CreatedAt = time.Now()
+		list.Cards = []FavoriteEntry{}
+		favorites.Lists = append(favorites.Lists, list)
+	}
+	return favorites
+}
+
+// FavoritesList renvoie la liste historique des favoris.
+func (f *Favorites) FavoritesList() *CardList {
+	return f.List(favoritesListSlug)
+}
+
+// loadFavorites lit les listes sans jamais écrire le fichier : un fichier absent ou à migrer donne
+// des listes en mémoire, enregistrées au prochain updateFavorites, sous favoritesMu. Une lecture
+// concurrente ne peut ainsi pas écraser une modification en cours. Un fichier illisible renvoie
+// une erreur enveloppant errFavoritesCorrupt.
+func loadFavorites() (Favorites, error) {
+	info, err := os.Stat(dataPath(favoritesFile))
+	if os.IsNotExist(err) {
+		return newFavorites(), nil
+	}
+
+	data, err := os.ReadFile(dataPath(favoritesFile))
+	if err != nil {
+		return newFavorites(), err
+	}
+
+	if len(data) == 0 {
+		return newFavorites(), nil
+	}
+
+	favorites, migrated, err := decodeFavorites(data, info.ModTime())
+	if err != nil {
+		return newFavorites(), fmt.Errorf("%w (%s): %v", errFavoritesCorrupt, dataPath(favoritesFile), err)
+	}
+
+	if migrated {
+		slog.Info("Migration du fichier de favoris, enregistrée à la prochaine modification", "version", favoritesSchemaVersion)
+	}
+
+	return favorites, nil
+}
+
+// decodeFavorites lit le contenu du fichier de favoris et le migre si nécessaire vers
+// la version actuelle du schéma. modTime sert de date d'ajout pour les anciennes entrées.
+func decodeFavorites(data []byte, modTime time.Time) (Favorites, bool, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Favorites{}, false, err
+	}
+
+	switch header.Version {
+	case 0:
+		var legacy struct {
+			Cards []Card `json:"cards"`
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return Favorites{}, false, err
+		}
+
+		favorites := newFavorites()
+		list := favorites.FavoritesList()
+		for _, card := range legacy.Cards {
+			if card.ID != "" {
+				list.Add(FavoriteEntry{ID: card.ID, AddedAt: modTime})
+			}
+		}
+		return favorites, true, nil
+
+	case 1:
+		var v1 struct {
+			Cards []FavoriteEntry `json:"cards"`
+		}
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return Favorites{}, false, err
+		}
+
+		favorites := newFavorites()
+		list := favorites.FavoritesList()
+		for _, entry := range v1.Cards {
+			list.Add(entry)
+		}
+		return favorites, true, nil
+	}
+
+	var favorites Favorites
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return Favorites{}, false, err
+	}
+
+	// La liste des favoris doit toujours exister pour les routes historiques.
+	if favorites.FavoritesList() == nil {
+		favorites.Lists = append([]CardList{{
+			Slug:      favoritesListSlug,
+			Name:      "Favoris",
+			CreatedAt: time.Now(),
+		}}, favorites.Lists...)
+	}
+	for i := range favorites.Lists {
+		if favorites.Lists[i].Cards == nil {
+			favorites.Lists[i].Cards = []FavoriteEntry{}
+		}
+	}
+
+	return favorites, false, nil
+}
+
+func saveFavorites(favorites Favorites) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	favorites.Version = favoritesSchemaVersion
+	data, err := json.Marshal(favorites)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(favoritesFile), data, 0644)
+}
@@ -0,0 +1,786 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Durée de validité d'un aperçu d'import avant confirmation.
+const pendingImportTTL = 30 * time.Minute
+
+// Taille maximale acceptée pour un fichier importé.
+const maxImportSize = 5 << 20
+
+// importRow est une ligne lue depuis un fichier importé, avant résolution.
+type importRow struct {
+	Line      int
+	Raw       string
+	CardID    string
+	SetID     string
+	LocalID   string
+	Quantity  int
+	Variant   string
+	Condition string
+	Grading   *Grading
+	Acquired  string
+	PricePaid float64
+	Currency  string
+	Note      string
+	// Invalid décrit un champ illisible de la ligne, signalée comme non résolue.
+	Invalid string
+}
+
+// importLot est une ligne résolue vers une carte du catalogue.
+type importLot struct {
+	Row  importRow
+	Card Card
+}
+
+// unresolvedRow décrit une ligne qui n'a pas pu être associée à une carte.
+type unresolvedRow struct {
+	Row    importRow
+	Reason string
+}
+
+// pendingImport conserve un import résolu en attente de confirmation.
+type pendingImport struct {
+	Target     string
+	Lots       []importLot
+	Unresolved []unresolvedRow
+	CreatedAt  time.Time
+}
+
+var pendingImports = struct {
+	sync.Mutex
+	byToken map[string]*pendingImport
+}{byToken: make(map[string]*pendingImport)}
+
+func storePendingImport(p *pendingImport) string {
+	pendingImports.Lock()
+	defer pendingImports.Unlock()
+
+	for token, pending := range pendingImports.byToken {
+		if time.Since(pending.CreatedAt) > pendingImportTTL {
+			delete(pendingImports.byToken, token)
+		}
+	}
+
+	token := newOwnedID()
+	pendingImports.byToken[token] = p
+	return token
+}
+
+func takePendingImport(token string) *pendingImport {
+	pendingImports.Lock()
+	defer pendingImports.Unlock()
+
+	p := pendingImports.byToken[token]
+	delete(pendingImports.byToken, token)
+	if p == nil || time.Since(p.CreatedAt) > pendingImportTTL {
+		return nil
+	}
+	return p
+}
+
+// exportTarget décrit ce qui est exporté ou importé : une liste ou la collection possédée.
+// La valeur "collection" désigne la collection, toute autre valeur un slug de liste.
+func exportTarget(r *http.Request) string {
+	if target := r.FormValue("target"); target != "" {
+		return target
+	}
+	return favoritesListSlug
+}
+
+// exportHandler gère /favorites/export?target={slug|collection}&format={json|csv|txt}.
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	target := exportTarget(r)
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var rows []importRow
+	var payload interface{}
+
+	if target == "collection" {
+		collection, err := loadCollection()
+		if err != nil {
+			showError(w, "Impossible de charger la collection", err)
+			return
+		}
+		payload = collection
+		for _, item := range collection.Items {
+			rows = append(rows, importRow{
+				CardID:    item.CardID,
+				SetID:     item.SetID,
+				LocalID:   item.LocalID,
+				Quantity:  item.Quantity,
+				Variant:   item.Variant,
+				Condition: item.Condition,
+				Grading:   item.Grading,
+				Acquired:  item.AcquiredAt,
+				PricePaid: item.PricePaid,
+				Currency:  item.Currency,
+				Note:      item.Note,
+			})
+		}
+	} else {
+		favorites, err := loadFavorites()
+		if err != nil {
+			showError(w, "Impossible de charger les listes", err)
+			return
+		}
+		list := favorites.List(target)
+		if list == nil {
+			showError(w, "Liste introuvable", fmt.Errorf("aucune liste %s", target))
+			return
+		}
+		payload = list
+		for _, entry := range list.Cards {
+			setID, localID := splitCardID(entry.ID)
+			rows = append(rows, importRow{CardID: entry.ID, SetID: setID, LocalID: localID, Quantity: 1, Note: entry.Note})
+		}
+	}
+
+	filename := "poketracker-" + target + "-" + time.Now().Format("2006-01-02")
+
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(payload)
+
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write(csvColumns)
+		for _, row := range rows {
+			company, grade := "", ""
+			if row.Grading != nil {
+				company, grade = row.Grading.Company, row.Grading.Grade
+			}
+			price := ""
+			if row.PricePaid > 0 {
+				price = strconv.FormatFloat(row.PricePaid, 'f', 2, 64)
+			}
+			cw.Write([]string{row.CardID, row.SetID, row.LocalID, strconv.Itoa(row.Quantity), row.Variant, row.Condition, company, grade, row.Acquired, price, row.Currency, row.Note})
+		}
+		cw.Flush()
+
+	case "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.txt"`)
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s %s %d\n", row.SetID, row.LocalID, row.Quantity)
+		}
+
+	default:
+		http.Error(w, "Format d'export inconnu: "+format, http.StatusBadRequest)
+	}
+}
+
+// Colonnes du format CSV, utilisées à l'export comme à l'import.
+var csvColumns = []string{"id", "set", "number", "quantity", "variant", "condition", "grading", "grade", "acquired", "price", "currency", "note"}
+
+func splitCardID(id string) (string, string) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return "", id
+	}
+	return id[:i], id[i+1:]
+}
+
+// parseImport lit les lignes d'un fichier importé selon son format.
+func parseImport(format string, data io.Reader) ([]importRow, error) {
+	switch format {
+	case "json":
+		return parseImportJSON(data)
+	case "csv":
+		return parseImportCSV(data)
+	case "txt":
+		return parseImportText(data)
+	}
+	return nil, fmt.Errorf("format d'import inconnu: %s", format)
+}
+
+func parseImportJSON(data io.Reader) ([]importRow, error) {
+	var doc struct {
+		Cards []FavoriteEntry `json:"cards"`
+		Items []OwnedCard     `json:"items"`
+	}
+	if err := json.NewDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("JSON invalide: %w", err)
+	}
+
+	var rows []importRow
+	for i, entry := range doc.Cards {
+		setID, localID := splitCardID(entry.ID)
+		rows = append(rows, importRow{Line: i + 1, Raw: entry.ID, CardID: entry.ID, SetID: setID, LocalID: localID, Quantity: 1, Note: entry.Note})
+	}
+	for i, item := range doc.Items {
+		row := importRow{
+			Line:      i + 1,
+			Raw:       item.CardID,
+			CardID:    item.CardID,
+			SetID:     item.SetID,
+			LocalID:   item.LocalID,
+			Quantity:  item.Quantity,
+			Variant:   item.Variant,
+			Condition: item.Condition,
+			Grading:   item.Grading,
+			Acquired:  item.AcquiredAt,
+			PricePaid: item.PricePaid,
+			Currency:  item.Currency,
+			Note:      item.Note,
+		}
+		if row.SetID == "" || row.LocalID == "" {
+			row.SetID, row.LocalID = splitCardID(item.CardID)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseImportCSV(data io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(data)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV invalide: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		if _, ok := columns["number"]; !ok {
+			return nil, fmt.Errorf("CSV sans colonne id ni number")
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []importRow
+	for n, record := range records[1:] {
+		row := importRow{
+			Line:      n + 2,
+			Raw:       strings.Join(record, ","),
+			CardID:    field(record, "id"),
+			SetID:     field(record, "set"),
+			LocalID:   field(record, "number"),
+			Quantity:  1,
+			Variant:   field(record, "variant"),
+			Condition: strings.ToUpper(field(record, "condition")),
+			Acquired:  field(record, "acquired"),
+			Currency:  field(record, "currency"),
+			Note:      field(record, "note"),
+		}
+		if quantity := field(record, "quantity"); quantity != "" {
+			if q, err := strconv.Atoi(quantity); err == nil {
+				row.Quantity = q
+			} else {
+				row.Quantity = 0
+			}
+		}
+		if company := field(record, "grading"); company != "" {
+			row.Grading = &Grading{Company: strings.ToUpper(company), Grade: field(record, "grade")}
+		}
+		if price := field(record, "price"); price != "" {
+			value, err := strconv.ParseFloat(strings.Replace(price, ",", ".", 1), 64)
+			if err != nil || value < 0 {
+				row.Invalid = fmt.Sprintf("prix d'achat invalide: %q", price)
+			}
+			row.PricePaid = value
+		}
+		if (row.SetID == "" || row.LocalID == "") && row.CardID != "" {
+			row.SetID, row.LocalID = splitCardID(row.CardID)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseImportText lit une liste « set-code numéro quantité », une carte par ligne.
+// La quantité est facultative, les lignes vides et commençant par # sont ignorées.
+func parseImportText(data io.Reader) ([]importRow, error) {
+	var rows []importRow
+	scanner := bufio.NewScanner(data)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+
+		fields := strings.Fields(raw)
+		row := importRow{Line: line, Raw: raw, Quantity: 1}
+		switch len(fields) {
+		case 1:
+			row.SetID, row.LocalID = splitCardID(fields[0])
+		case 2:
+			row.SetID, row.LocalID = fields[0], fields[1]
+		default:
+			row.SetID, row.LocalID = fields[0], fields[1]
+			q, err := strconv.Atoi(strings.TrimPrefix(fields[2], "x"))
+			if err != nil {
+				row.Quantity = 0
+			} else {
+				row.Quantity = q
+			}
+		}
+		// Un numéro au format « 125/197 » ne garde que le numéro local.
+		row.LocalID, _, _ = strings.Cut(row.LocalID, "/")
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// resolveImportRows associe chaque ligne à une carte via l'ID du set et le numéro local.
+// Les cartes de chaque set ne sont récupérées qu'une fois.
+func resolveImportRows(ctx context.Context, rows []importRow) ([]importLot, []unresolvedRow) {
+	setCards := make(map[string]map[string]Card)
+	setErrors := make(map[string]error)
+
+	var lots []importLot
+	var unresolved []unresolvedRow
+
+	for _, row := range rows {
+		if row.Invalid != "" {
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: row.Invalid})
+			continue
+		}
+		if row.Quantity < 1 {
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: "quantité invalide"})
+			continue
+		}
+		if row.SetID == "" || row.LocalID == "" {
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: "set ou numéro manquant"})
+			continue
+		}
+
+		index, fetched := setCards[row.SetID]
+		if !fetched && setErrors[row.SetID] == nil {
+			cards, err := fetchSetCards(ctx, row.SetID, 0)
+			if err != nil {
+				setErrors[row.SetID] = err
+			} else {
+				index = make(map[string]Card)
+				for _, card := range cards {
+					index[normalizeLocalID(card.LocalId)] = card
+				}
+				setCards[row.SetID] = index
+			}
+		}
+
+		if err := setErrors[row.SetID]; err != nil {
+			reason := "set inaccessible"
+			if isNotFound(err) {
+				reason = "set inconnu"
+			}
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: reason + ": " + row.SetID})
+			continue
+		}
+
+		card, ok := index[normalizeLocalID(row.LocalID)]
+		if !ok {
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: "numéro " + row.LocalID + " absent du set " + row.SetID})
+			continue
+		}
+
+		// Les champs du lot sont validés comme dans le formulaire de la collection.
+		if err := validateOwnedCard(ownedFromLot(importLot{Row: row, Card: card}), card); err != nil {
+			unresolved = append(unresolved, unresolvedRow{Row: row, Reason: err.Error()})
+			continue
+		}
+
+		lots = append(lots, importLot{Row: row, Card: card})
+	}
+
+	return lots, unresolved
+}
+
+// normalizeLocalID supprime les zéros initiaux d'un numéro local pour la comparaison.
+func normalizeLocalID(localID string) string {
+	trimmed := strings.TrimLeft(strings.ToLower(localID), "0")
+	if trimmed == "" {
+		return "0"
+	}
+	return trimmed
+}
+
+// ownedFromLot construit un lot possédé à partir d'une ligne importée résolue.
+func ownedFromLot(lot importLot) OwnedCard {
+	item := OwnedCard{
+		CardID:     lot.Card.ID,
+		SetID:      lot.Row.SetID,
+		LocalID:    lot.Card.LocalId,
+		Quantity:   lot.Row.Quantity,
+		Variant:    lot.Row.Variant,
+		Condition:  lot.Row.Condition,
+		Grading:    lot.Row.Grading,
+		AcquiredAt: lot.Row.Acquired,
+		PricePaid:  lot.Row.PricePaid,
+		Currency:   lot.Row.Currency,
+		Note:       lot.Row.Note,
+	}
+	if item.Variant == "" {
+		item.Variant = "normal"
+	}
+	if item.Condition == "" {
+		item.Condition = "NM"
+	}
+	item.Currency = strings.ToUpper(item.Currency)
+	if item.PricePaid > 0 && item.Currency == "" {
+		item.Currency = "EUR"
+	}
+	return item
+}
+
+// sameLot indique si deux lots possédés décrivent les mêmes exemplaires.
+func sameLot(a, b OwnedCard) bool {
+	if a.CardID != b.CardID || a.Variant != b.Variant || a.Condition != b.Condition {
+		return false
+	}
+	if (a.Grading == nil) != (b.Grading == nil) {
+		return false
+	}
+	return a.Grading == nil || *a.Grading == *b.Grading
+}
+
+// importDiff décrit l'effet d'un import sur la cible, ligne par ligne.
+type importDiff struct {
+	Added     []string
+	Updated   []string
+	Unchanged []string
+	Removed   []string
+}
+
+// computeImportDiff compare l'import à l'état actuel de la cible, pour le mode donné.
+func computeImportDiff(p *pendingImport, replace bool) (importDiff, error) {
+	var diff importDiff
+
+	if p.Target == "collection" {
+		collection, err := loadCollection()
+		if err != nil {
+			return diff, err
+		}
+		merged := mergeCollection(collection, p.Lots, replace)
+
+		for _, item := range merged.Items {
+			label := item.CardID + " (" + item.Variant + ", " + item.Condition + ")"
+			var before *OwnedCard
+			for i := range collection.Items {
+				if sameLot(collection.Items[i], item) {
+					before = &collection.Items[i]
+					break
+				}
+			}
+			switch {
+			case before == nil:
+				diff.Added = append(diff.Added, label+" × "+strconv.Itoa(item.Quantity))
+			case before.Quantity != item.Quantity:
+				diff.Updated = append(diff.Updated, label+" : "+strconv.Itoa(before.Quantity)+" → "+strconv.Itoa(item.Quantity))
+			default:
+				diff.Unchanged = append(diff.Unchanged, label)
+			}
+		}
+		for _, item := range collection.Items {
+			kept := false
+			for _, m := range merged.Items {
+				if sameLot(item, m) {
+					kept = true
+					break
+				}
+			}
+			if !kept {
+				diff.Removed = append(diff.Removed, item.CardID+" ("+item.Variant+", "+item.Condition+") × "+strconv.Itoa(item.Quantity))
+			}
+		}
+		return diff, nil
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		return diff, err
+	}
+	list := favorites.List(p.Target)
+	if list == nil {
+		return diff, errListNotFound
+	}
+
+	imported := make(map[string]bool)
+	for _, lot := range p.Lots {
+		if imported[lot.Card.ID] {
+			continue
+		}
+		imported[lot.Card.ID] = true
+		if list.Contains(lot.Card.ID) {
+			diff.Unchanged = append(diff.Unchanged, lot.Card.ID)
+		} else {
+			diff.Added = append(diff.Added, lot.Card.ID+" "+lot.Card.Name)
+		}
+	}
+	if replace {
+		for _, entry := range list.Cards {
+			if !imported[entry.ID] {
+				diff.Removed = append(diff.Removed, entry.ID)
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// mergeCollection applique les lots importés à la collection.
+// En fusion, les quantités des lots identiques sont additionnées ; en remplacement,
+// la collection est reconstruite à partir de l'import uniquement.
+func mergeCollection(collection Collection, lots []importLot, replace bool) Collection {
+	result := newCollection()
+	if !replace {
+		result.Items = append(result.Items, collection.Items...)
+	}
+
+	for _, lot := range lots {
+		item := ownedFromLot(lot)
+		merged := false
+		for i := range result.Items {
+			if sameLot(result.Items[i], item) {
+				result.Items[i].Quantity += item.Quantity
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			item.ID = newOwnedID()
+			result.Items = append(result.Items, item)
+		}
+	}
+
+	return result
+}
+
+// importHandler gère /favorites/import : formulaire, aperçu (POST) et confirmation (POST confirm).
+func importHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		renderImportForm(w, "")
+		return
+	}
+
+	if token := r.FormValue("token"); token != "" {
+		confirmImport(w, r, token)
+		return
+	}
+
+	r.ParseMultipartForm(maxImportSize)
+
+	var data io.Reader = strings.NewReader(r.FormValue("data"))
+	if file, _, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		data = io.LimitReader(file, maxImportSize)
+	}
+
+	rows, err := parseImport(r.FormValue("format"), data)
+	if err != nil {
+		renderImportForm(w, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		renderImportForm(w, "Aucune ligne à importer.")
+		return
+	}
+
+	lots, unresolved := resolveImportRows(ctx, rows)
+	pending := &pendingImport{
+		Target:     exportTarget(r),
+		Lots:       lots,
+		Unresolved: unresolved,
+		CreatedAt:  time.Now(),
+	}
+
+	replace := r.FormValue("mode") == "replace"
+	diff, err := computeImportDiff(pending, replace)
+	if err != nil {
+		renderImportForm(w, "Cible d'import invalide: "+err.Error())
+		return
+	}
+
+	token := storePendingImport(pending)
+	slog.InfoContext(r.Context(), "Aperçu d'import", "token", token, "resolved", len(lots), "unresolved", len(unresolved))
+
+	mode := "merge"
+	modeLabel := "Fusion avec le contenu existant"
+	if replace {
+		mode = "replace"
+		modeLabel = "Remplacement du contenu existant"
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Aperçu de l'import</h2>
+            <p class="results-count">Cible : ` + escape(pending.Target) + ` — ` + modeLabel + `</p>
+        </div>
+
+        <div class="import-preview">`
+	html += renderDiffSection("Ajouts", "added", diff.Added)
+	html += renderDiffSection("Quantités modifiées", "updated", diff.Updated)
+	html += renderDiffSection("Suppressions", "removed", diff.Removed)
+	html += renderDiffSection("Inchangées", "unchanged", diff.Unchanged)
+
+	var unresolvedLines []string
+	for _, u := range unresolved {
+		unresolvedLines = append(unresolvedLines, "Ligne "+strconv.Itoa(u.Row.Line)+" « "+u.Row.Raw+" » : "+u.Reason)
+	}
+	html += renderDiffSection("Lignes non résolues", "unresolved", unresolvedLines)
+
+	html += `
+        </div>
+
+        <form action="/favorites/import" method="POST" class="list-form">
+            <input type="hidden" name="token" value="` + token + `">
+            <input type="hidden" name="mode" value="` + mode + `">
+            <button type="submit" class="button">Confirmer l'import</button>
+            <a href="/favorites/import" class="button outline">Annuler</a>
+        </form>`
+
+	writePage(w, "Aperçu de l'import", html, "")
+}
+
+func renderDiffSection(title, class string, lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	sort.Strings(lines)
+	html := `
+            <section class="diff-section ` + class + `">
+                <h3>` + title + ` (` + strconv.Itoa(len(lines)) + `)</h3>
+                <ul>`
+	for _, line := range lines {
+		html += `
+                    <li>` + escape(line) + `</li>`
+	}
+	return html + `
+                </ul>
+            </section>`
+}
+
+func confirmImport(w http.ResponseWriter, r *http.Request, token string) {
+	pending := takePendingImport(token)
+	if pending == nil {
+		renderImportForm(w, "Cet aperçu d'import a expiré, veuillez recommencer.")
+		return
+	}
+
+	replace := r.FormValue("mode") == "replace"
+
+	var err error
+	redirect := "/collection"
+	if pending.Target == "collection" {
+		err = updateCollection(func(c *Collection) error {
+			*c = mergeCollection(*c, pending.Lots, replace)
+			return nil
+		})
+	} else {
+		redirect = "/lists/" + pending.Target
+		err = updateFavorites(func(f *Favorites) error {
+			list := f.List(pending.Target)
+			if list == nil {
+				return errListNotFound
+			}
+			if replace {
+				list.Cards = []FavoriteEntry{}
+			}
+			for _, lot := range pending.Lots {
+				list.Add(FavoriteEntry{ID: lot.Card.ID, AddedAt: time.Now(), Note: lot.Row.Note})
+			}
+			return nil
+		})
+	}
+
+	if err != nil {
+		showError(w, "Impossible d'appliquer l'import", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Import appliqué", "token", token, "target", pending.Target, "lines", len(pending.Lots), "replace", replace)
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+func renderImportForm(w http.ResponseWriter, errMsg string) {
+	favorites, _ := loadFavorites()
+
+	html := `
+        <div class="page-header">
+            <h2>Importer / Exporter</h2>
+        </div>`
+
+	if errMsg != "" {
+		html += `
+        <div class="error-message">` + escape(errMsg) + `</div>`
+	}
+
+	targets := `<option value="collection">Ma collection</option>`
+	for _, list := range favorites.Lists {
+		targets += `<option value="` + list.Slug + `"` + selected(list.Slug == favoritesListSlug) + `>` + escape(list.Name) + `</option>`
+	}
+
+	html += `
+        <form action="/favorites/export" method="GET" class="list-form">
+            <h3>Exporter</h3>
+            <select name="target">` + targets + `</select>
+            <select name="format">
+                <option value="json">JSON</option>
+                <option value="csv">CSV</option>
+                <option value="txt">Texte (set numéro quantité)</option>
+            </select>
+            <button type="submit" class="button secondary">Télécharger</button>
+        </form>
+
+        <form action="/favorites/import" method="POST" enctype="multipart/form-data" class="list-form import-form">
+            <h3>Importer</h3>
+            <select name="target">` + targets + `</select>
+            <select name="format">
+                <option value="txt">Texte (set numéro quantité)</option>
+                <option value="csv">CSV</option>
+                <option value="json">JSON</option>
+            </select>
+            <select name="mode">
+                <option value="merge">Fusionner</option>
+                <option value="replace">Remplacer</option>
+            </select>
+            <input type="file" name="file">
+            <textarea name="data" rows="8" placeholder="sv03 125 2&#10;sv01 4 1"></textarea>
+            <button type="submit" class="button">Prévisualiser</button>
+        </form>`
+
+	writePage(w, "Importer / Exporter", html, "")
+}
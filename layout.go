@@ -0,0 +1,63 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// escape protège les valeurs saisies par l'utilisateur insérées dans le HTML généré.
+func escape(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// writePage envoie une page complète avec l'en-tête, la navigation et le pied de page communs.
+func writePage(w http.ResponseWriter, title, content, scripts string) {
+	html := `<!DOCTYPE html>
+<html lang="fr">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>` + escape(title) + ` - PokéTracker</title>
+    <link rel="stylesheet" href="/static/css/style.css">
+</head>
+<body>
+    <header>
+        <div class="container">
+            <h1><a href="/">PokéTracker</a></h1>
+            <nav>
+                <ul>
+                    <li><a href="/">Accueil</a></li>
+                    <li><a href="/cards">Cartes</a></li>
+                    <li><a href="/sets">Collections</a></li>
+                    <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
+                    <li><a href="/about">À propos</a></li>
+                </ul>
+            </nav>
+            <form action="/search" method="GET" class="search-form">
+                <input type="text" name="q" placeholder="Rechercher des cartes..." required>
+                <button type="submit">Rechercher</button>
+            </form>
+        </div>
+    </header>
+` + degradedBanner() + `
+
+    <main class="container">
+` + content + `
+    </main>
+
+    <footer>
+        <div class="container">
+            <p>&copy; 2025 PokéTracker - Créé pour le projet Groupie Tracker</p>
+        </div>
+    </footer>
+` + scripts + `
+</body>
+</html>`
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job est une tâche de fond : Run tourne jusqu'à l'annulation de son contexte.
+// StopTimeout borne l'attente de son arrêt.
+type Job struct {
+	Name        string
+	Run         func(ctx context.Context)
+	StopTimeout time.Duration
+}
+
+type runningJob struct {
+	job    Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Lifecycle démarre les tâches de fond dans l'ordre et les arrête dans l'ordre inverse,
+// chacune avec son propre délai. Les tâches ponctuelles lancées par Go sont attendues à l'arrêt.
+type Lifecycle struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	jobs     []*runningJob
+	tasks    sync.WaitGroup
+	stopping bool
+}
+
+// Délai d'arrêt d'une tâche qui n'en précise pas.
+const defaultJobStopTimeout = 10 * time.Second
+
+func newLifecycle() *Lifecycle {
+	// Les appels à l'API des tâches de fond passent après ceux des visiteurs.
+	ctx, cancel := context.WithCancel(withBackgroundPriority(context.Background()))
+	return &Lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// lifecycle gère les tâches de fond de l'application.
+var lifecycle = newLifecycle()
+
+// Start lance une tâche de fond.
+func (l *Lifecycle) Start(job Job) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping {
+		slog.Warn("Tâche non démarrée: arrêt en cours", "job", job.Name)
+		return
+	}
+	if job.StopTimeout <= 0 {
+		job.StopTimeout = defaultJobStopTimeout
+	}
+
+	ctx, cancel := context.WithCancel(l.ctx)
+	running := &runningJob{job: job, cancel: cancel, done: make(chan struct{})}
+	l.jobs = append(l.jobs, running)
+
+	slog.Info("Démarrage de la tâche", "job", job.Name)
+	go func() {
+		defer close(running.done)
+		job.Run(ctx)
+	}()
+}
+
+// Go lance une tâche ponctuelle, annulée à l'arrêt de l'application.
+// Elle renvoie false si l'arrêt est déjà en cours.
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context)) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping {
+		slog.Warn("Tâche non lancée: arrêt en cours", "job", name)
+		return false
+	}
+	l.tasks.Add(1)
+	go func() {
+		defer l.tasks.Done()
+		fn(l.ctx)
+	}()
+	return true
+}
+
+// Stop arrête les tâches dans l'ordre inverse de leur démarrage puis attend les tâches ponctuelles,
+// sans dépasser l'échéance de ctx. Une tâche qui ne s'arrête pas dans son délai est abandonnée.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	l.stopping = true
+	jobs := l.jobs
+	l.mu.Unlock()
+
+	var late []string
+	for i := len(jobs) - 1; i >= 0; i-- {
+		running := jobs[i]
+		running.cancel()
+
+		timer := time.NewTimer(running.job.StopTimeout)
+		select {
+		case <-running.done:
+			slog.Info("Tâche arrêtée", "job", running.job.Name)
+		case <-timer.C:
+			slog.Warn("La tâche ne s'est pas arrêtée dans son délai", "job", running.job.Name, "timeout", running.job.StopTimeout)
+			late = append(late, running.job.Name)
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("arrêt des tâches interrompu avant %s: %w", running.job.Name, ctx.Err())
+		}
+		timer.Stop()
+	}
+
+	l.cancel()
+	done := make(chan struct{})
+	go func() {
+		l.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("tâches ponctuelles toujours en cours: %w", ctx.Err())
+	}
+
+	if len(late) > 0 {
+		return fmt.Errorf("tâches non arrêtées à temps: %v", late)
+	}
+	return nil
+}
+
+// sleepContext attend d, ou moins si ctx est annulé. Elle renvoie false dans ce cas.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
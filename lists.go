@@ -0,0 +1,546 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CardList est une liste nommée de cartes (favoris, recherchées, à échanger, classeur...).
+type CardList struct {
+	Slug        string          `json:"slug"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Cards       []FavoriteEntry `json:"cards"`
+}
+
+// Listes créées pour un nouveau fichier ou lors d'une migration.
+var defaultLists = []CardList{
+	{Slug: favoritesListSlug, Name: "Favoris"},
+	{Slug: "want", Name: "Recherchées", Description: "Cartes que je cherche à obtenir"},
+	{Slug: "trade", Name: "À échanger", Description: "Cartes disponibles pour l'échange"},
+}
+
+// Contains indique si la carte fait partie de la liste.
+func (l *CardList) Contains(id string) bool {
+	for _, entry := range l.Cards {
+		if entry.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IDs renvoie les IDs des cartes de la liste dans l'ordre d'ajout.
+func (l *CardList) IDs() []string {
+	ids := make([]string, len(l.Cards))
+	for i, entry := range l.Cards {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+// Add ajoute une entrée à la liste si la carte n'y est pas déjà.
+func (l *CardList) Add(entry FavoriteEntry) bool {
+	if entry.ID == "" || l.Contains(entry.ID) {
+		return false
+	}
+	l.Cards = append(l.Cards, entry)
+	return true
+}
+
+// Remove retire la carte de la liste et renvoie l'entrée retirée.
+func (l *CardList) Remove(id string) (FavoriteEntry, bool) {
+	for i, entry := range l.Cards {
+		if entry.ID == id {
+			l.Cards = append(l.Cards[:i], l.Cards[i+1:]...)
+			return entry, true
+		}
+	}
+	return FavoriteEntry{}, false
+}
+
+// List renvoie la liste correspondant au slug, ou nil si elle n'existe pas.
+func (f *Favorites) List(slug string) *CardList {
+	for i := range f.Lists {
+		if f.Lists[i].Slug == slug {
+			return &f.Lists[i]
+		}
+	}
+	return nil
+}
+
+// CreateList ajoute une nouvelle liste vide avec un slug unique dérivé de son nom.
+func (f *Favorites) CreateList(name, description string) *CardList {
+	base := slugify(name)
+	if base == "" {
+		base = "liste"
+	}
+
+	slug := base
+	for i := 2; f.List(slug) != nil; i++ {
+		slug = base + "-" + strconv.Itoa(i)
+	}
+
+	f.Lists = append(f.Lists, CardList{
+		Slug:        slug,
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+		Cards:       []FavoriteEntry{},
+	})
+	return &f.Lists[len(f.Lists)-1]
+}
+
+// DeleteList supprime une liste. La liste des favoris ne peut pas être supprimée.
+func (f *Favorites) DeleteList(slug string) bool {
+	if slug == favoritesListSlug {
+		return false
+	}
+	for i := range f.Lists {
+		if f.Lists[i].Slug == slug {
+			f.Lists = append(f.Lists[:i], f.Lists[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ListsContaining renvoie les listes dont fait partie la carte.
+func (f *Favorites) ListsContaining(id string) []*CardList {
+	var lists []*CardList
+	for i := range f.Lists {
+		if f.Lists[i].Contains(id) {
+			lists = append(lists, &f.Lists[i])
+		}
+	}
+	return lists
+}
+
+// Remplacement des caractères accentués courants pour la génération des slugs.
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i",
+	"ô", "o", "ö", "o", "ó", "o",
+	"ù", "u", "û", "u", "ü", "u", "ú", "u",
+	"ç", "c", "ñ", "n", "œ", "oe", "æ", "ae",
+	"ā", "a", "ē", "e", "ī", "i", "ō", "o", "ū", "u",
+)
+
+// slugify transforme un nom de liste en identifiant utilisable dans une URL.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range accentReplacer.Replace(strings.ToLower(name)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// errListNotFound est renvoyée lorsqu'une liste demandée n'existe pas.
+var errListNotFound = fmt.Errorf("liste introuvable")
+
+// updateFavorites charge les listes, applique la modification puis les sauvegarde,
+// le tout sous le verrou du fichier de favoris. Un fichier illisible est d'abord mis de côté
+// (favoritesBackupPath) pour ne pas être écrasé.
+func updateFavorites(apply func(*Favorites) error) error {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	favorites, err := loadFavorites()
+	if errors.Is(err, errFavoritesCorrupt) {
+		backup := favoritesBackupPath()
+		if renameErr := os.Rename(dataPath(favoritesFile), backup); renameErr != nil {
+			return fmt.Errorf("%v ; impossible de le mettre de côté: %w", err, renameErr)
+		}
+		slog.Warn("Fichier de favoris illisible mis de côté, nouvelles listes créées", "backup", backup, "err", err)
+	} else if err != nil {
+		return err
+	}
+
+	if err := apply(&favorites); err != nil {
+		return err
+	}
+
+	return saveFavorites(favorites)
+}
+
+// favoritesBackupPath renvoie le fichier où mettre de côté un fichier de favoris illisible :
+// favorites.json.bak, ou un nom daté si une sauvegarde précédente existe déjà.
+func favoritesBackupPath() string {
+	backup := dataPath(favoritesFile) + ".bak"
+	if _, err := os.Stat(backup); err == nil {
+		backup = dataPath(favoritesFile) + "." + time.Now().Format("20060102-150405") + ".bak"
+	}
+	return backup
+}
+
+// renderCardTile génère la vignette d'une carte hydratée, suivie des contrôles fournis.
+func renderCardTile(h HydratedCard, controls string) string {
+	if h.Unknown || h.Err != nil {
+		status := "Carte introuvable dans le catalogue"
+		if h.Err != nil {
+			status = "Données momentanément indisponibles"
+		}
+		return `
+            <div class="card favorite-card unknown-card">
+                <div class="card-content">
+                    <h3>` + escape(h.ID) + `</h3>
+                    <p class="unknown-marker">` + status + `</p>
+                </div>
+                ` + controls + `
+            </div>`
+	}
+
+	card := h.Card
+	html := `
+            <div class="card favorite-card">
+                <a href="/card/` + card.ID + `">
+                    <img src="` + card.Image + `" alt="` + escape(card.Name) + `">
+                    <div class="card-content">
+                        <h3>` + escape(card.Name) + `</h3>
+                        <p>` + escape(card.Set.Name) + `</p>`
+
+	if len(card.Types) > 0 {
+		html += `<div class="card-types">`
+		for _, cardType := range card.Types {
+			html += `<span class="type ` + cardType + `">` + cardType + `</span>`
+		}
+		html += `</div>`
+	}
+
+	html += `
+                    </div>
+                </a>
+                ` + controls + `
+            </div>`
+	return html
+}
+
+func listsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			http.Error(w, "Nom de liste requis", http.StatusBadRequest)
+			return
+		}
+
+		var slug string
+		err := updateFavorites(func(f *Favorites) error {
+			slug = f.CreateList(name, strings.TrimSpace(r.FormValue("description"))).Slug
+			return nil
+		})
+		if err != nil {
+			showError(w, "Impossible de créer la liste", err)
+			return
+		}
+
+		http.Redirect(w, r, "/lists/"+slug, http.StatusSeeOther)
+		return
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		showError(w, "Impossible de charger les listes", err)
+		return
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Mes Listes</h2>
+            <p>Organisez vos cartes en listes : recherchées, à échanger, idées de deck, classeurs...</p>
+        </div>
+
+        <div class="list-grid">`
+
+	for _, list := range favorites.Lists {
+		html += `
+            <a class="list-tile" href="/lists/` + list.Slug + `">
+                <h3>` + escape(list.Name) + `</h3>
+                <p>` + strconv.Itoa(len(list.Cards)) + ` cartes</p>`
+		if list.Description != "" {
+			html += `<p class="list-description">` + escape(list.Description) + `</p>`
+		}
+		html += `
+            </a>`
+	}
+
+	html += `
+        </div>
+
+        <form action="/lists" method="POST" class="list-form">
+            <h3>Nouvelle liste</h3>
+            <input type="text" name="name" placeholder="Nom de la liste (ex: Classeur 1)" required>
+            <input type="text" name="description" placeholder="Description (facultative)">
+            <button type="submit" class="button">Créer</button>
+        </form>
+
+        <div class="favorites-actions">
+            <a href="/favorites/import" class="button secondary">Importer / Exporter</a>
+        </div>`
+
+	writePage(w, "Mes Listes", html, "")
+}
+
+func listHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/lists/"), "/"), "/")
+	slug := parts[0]
+	if slug == "" {
+		http.Redirect(w, r, "/lists", http.StatusSeeOther)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	if action != "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var err error
+		redirect := "/lists/" + slug
+		switch action {
+		case "edit":
+			name := strings.TrimSpace(r.FormValue("name"))
+			err = updateFavorites(func(f *Favorites) error {
+				list := f.List(slug)
+				if list == nil {
+					return errListNotFound
+				}
+				if name != "" {
+					list.Name = name
+				}
+				list.Description = strings.TrimSpace(r.FormValue("description"))
+				return nil
+			})
+		case "delete":
+			redirect = "/lists"
+			err = updateFavorites(func(f *Favorites) error {
+				if !f.DeleteList(slug) {
+					return fmt.Errorf("la liste %s ne peut pas être supprimée", slug)
+				}
+				return nil
+			})
+		default:
+			showError(w, "Page non trouvée", fmt.Errorf("action de liste inconnue: %s", action))
+			return
+		}
+
+		if err != nil {
+			showError(w, "Impossible de modifier la liste", err)
+			return
+		}
+
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		showError(w, "Impossible de charger les listes", err)
+		return
+	}
+
+	list := favorites.List(slug)
+	if list == nil {
+		showError(w, "Liste introuvable", fmt.Errorf("aucune liste %s", slug))
+		return
+	}
+
+	moveOptions := `<option value="">Déplacer vers...</option>`
+	for _, other := range favorites.Lists {
+		if other.Slug != list.Slug {
+			moveOptions += `<option value="` + other.Slug + `">` + escape(other.Name) + `</option>`
+		}
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>` + escape(list.Name) + `</h2>`
+	if list.Description != "" {
+		html += `
+            <p>` + escape(list.Description) + `</p>`
+	}
+	html += `
+            <p class="results-count">` + strconv.Itoa(len(list.Cards)) + ` cartes</p>
+        </div>`
+
+	if len(list.Cards) > 0 {
+		html += `
+        <div class="card-grid fade-in">`
+
+		for _, h := range hydrateCards(ctx, list.IDs()) {
+			controls := `<div class="list-card-controls">
+                    <select class="move-card" data-id="` + escape(h.ID) + `">` + moveOptions + `</select>
+                    <button class="remove-favorite" data-id="` + escape(h.ID) + `">Retirer</button>
+                </div>`
+			html += renderCardTile(h, controls)
+		}
+
+		html += `
+        </div>`
+	} else {
+		html += `
+        <div class="no-favorites">
+            <p>Cette liste est vide.</p>
+            <p>Parcourez les <a href="/cards">cartes</a> et ajoutez-les depuis leur page de détail.</p>
+        </div>`
+	}
+
+	html += `
+        <form action="/lists/` + list.Slug + `/edit" method="POST" class="list-form">
+            <h3>Modifier la liste</h3>
+            <input type="text" name="name" value="` + escape(list.Name) + `" required>
+            <input type="text" name="description" value="` + escape(list.Description) + `" placeholder="Description (facultative)">
+            <button type="submit" class="button">Enregistrer</button>
+        </form>`
+
+	html += `
+        <div class="favorites-actions">
+            <a href="/favorites/export?target=` + list.Slug + `&format=json" class="button secondary">Exporter en JSON</a>
+            <a href="/favorites/export?target=` + list.Slug + `&format=csv" class="button secondary">Exporter en CSV</a>
+            <a href="/favorites/export?target=` + list.Slug + `&format=txt" class="button secondary">Exporter en texte</a>
+            <a href="/analytics?list=` + list.Slug + `" class="button secondary">Statistiques</a>
+            <a href="/value?target=` + list.Slug + `" class="button secondary">Valeur</a>
+        </div>`
+
+	if list.Slug != favoritesListSlug {
+		html += `
+        <form action="/lists/` + list.Slug + `/delete" method="POST" class="list-form" onsubmit="return confirm('Supprimer cette liste ?');">
+            <button type="submit" class="button danger">Supprimer la liste</button>
+        </form>`
+	}
+
+	scripts := `
+    <script>
+        document.addEventListener('DOMContentLoaded', function() {
+            const slug = '` + list.Slug + `';
+
+            document.querySelectorAll('.remove-favorite').forEach(button => {
+                button.addEventListener('click', function(e) {
+                    e.preventDefault();
+                    fetch('/api/list/' + slug + '/remove/' + this.getAttribute('data-id'))
+                        .then(response => {
+                            if (response.ok) {
+                                window.location.reload();
+                            }
+                        });
+                });
+            });
+
+            document.querySelectorAll('.move-card').forEach(select => {
+                select.addEventListener('change', function() {
+                    if (!this.value) {
+                        return;
+                    }
+                    fetch('/api/list/' + slug + '/move/' + this.getAttribute('data-id') + '?to=' + encodeURIComponent(this.value))
+                        .then(response => {
+                            if (response.ok) {
+                                window.location.reload();
+                            }
+                        });
+                });
+            });
+        });
+    </script>`
+
+	writePage(w, list.Name, html, scripts)
+}
+
+// listAPIHandler gère /api/list/{slug}/{add|remove|move|clear}/{id}.
+func listAPIHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/list/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		http.Error(w, "Requête de liste invalide", http.StatusBadRequest)
+		return
+	}
+
+	slug, action := parts[0], parts[1]
+	cardID := ""
+	if len(parts) == 3 {
+		cardID = parts[2]
+	}
+
+	if action != "clear" && cardID == "" {
+		http.Error(w, "ID de carte requis", http.StatusBadRequest)
+		return
+	}
+
+	status, err := applyListAction(ctx, slug, action, cardID, r.FormValue("to"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// applyListAction exécute une action de liste et renvoie le code HTTP adapté en cas d'erreur.
+func applyListAction(ctx context.Context, slug, action, cardID, target string) (int, error) {
+	if action == "add" {
+		if err := requireFreshData(); err != nil {
+			return http.StatusServiceUnavailable, err
+		}
+		if _, err := getCard(ctx, cardID); err != nil {
+			if isNotFound(err) {
+				return http.StatusNotFound, fmt.Errorf("Carte inconnue: %s", cardID)
+			}
+			return http.StatusInternalServerError, fmt.Errorf("Impossible de récupérer la carte: %v", err)
+		}
+	}
+
+	status := http.StatusInternalServerError
+	err := updateFavorites(func(f *Favorites) error {
+		list := f.List(slug)
+		if list == nil {
+			status = http.StatusNotFound
+			return errListNotFound
+		}
+
+		switch action {
+		case "add":
+			list.Add(FavoriteEntry{ID: cardID, AddedAt: time.Now()})
+		case "remove":
+			list.Remove(cardID)
+		case "clear":
+			list.Cards = []FavoriteEntry{}
+		case "move":
+			dest := f.List(target)
+			if dest == nil {
+				status = http.StatusNotFound
+				return fmt.Errorf("liste de destination introuvable: %s", target)
+			}
+			if entry, ok := list.Remove(cardID); ok {
+				dest.Add(entry)
+			}
+		default:
+			status = http.StatusBadRequest
+			return fmt.Errorf("action de liste inconnue: %s", action)
+		}
+		return nil
+	})
+
+	return status, err
+}
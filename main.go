@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -33,6 +39,23 @@ type Card struct {
 	Category       string   `json:"category,omitempty"`
 	LocalId        string   `json:"localId,omitempty"`
 	RegulationMark string   `json:"regulationMark,omitempty"`
+	Variants       Variants `json:"variants,omitempty"`
+	Stage          string   `json:"stage,omitempty"`
+	Suffix         string   `json:"suffix,omitempty"`
+	TrainerType    string   `json:"trainerType,omitempty"`
+	EnergyType     string   `json:"energyType,omitempty"`
+	Legal          Legal    `json:"legal,omitempty"`
+	Attacks        []Attack `json:"attacks,omitempty"`
+	Effect         string   `json:"effect,omitempty"`
+	Pricing        Pricing  `json:"pricing,omitempty"`
+	DexID          []int    `json:"dexId,omitempty"`
+}
+
+type Attack struct {
+	Name   string   `json:"name"`
+	Cost   []string `json:"cost,omitempty"`
+	Damage any      `json:"damage,omitempty"`
+	Effect string   `json:"effect,omitempty"`
 }
 
 type Images struct {
@@ -48,6 +71,12 @@ type Set struct {
 	CardCount   CardCount `json:"cardCount,omitempty"`
 	ReleaseDate string    `json:"releaseDate,omitempty"`
 	Legal       Legal     `json:"legal,omitempty"`
+	TCGOnline   string    `json:"tcgOnline,omitempty"`
+	Abbr        Abbr      `json:"abbreviation,omitempty"`
+}
+
+type Abbr struct {
+	Official string `json:"official,omitempty"`
 }
 
 type CardCount struct {
@@ -62,13 +91,11 @@ type Legal struct {
 	Unlimited bool `json:"unlimited,omitempty"`
 }
 
-type Favorites struct {
-	Cards []Card `json:"cards"`
-}
-
-func init() {
+// loadTemplates charge les templates HTML correspondant au motif pattern.
+func loadTemplates(pattern string) error {
 
 	funcMap := template.FuncMap{
+		"degradedBanner": degradedBannerHTML,
 		"add": func(a, b int) int {
 			return a + b
 		},
@@ -109,40 +136,61 @@ func init() {
 		},
 	}
 
-	if _, err := os.Stat("templates"); os.IsNotExist(err) {
-		log.Fatalf("ERREUR: Le dossier 'templates' n'existe pas. Veuillez le créer.")
+	if matches, _ := filepath.Glob(pattern); len(matches) == 0 {
+		return fmt.Errorf("aucun template ne correspond à %q", pattern)
 	}
 
-	log.Println("Chargement des templates...")
+	slog.Debug("Chargement des templates", "pattern", pattern)
 
-	var err error
-	templates, err = template.New("").Funcs(funcMap).ParseGlob("templates/*.html")
+	parsed, err := template.New("").Funcs(funcMap).ParseGlob(pattern)
 	if err != nil {
-		log.Fatalf("Erreur fatale lors du chargement des templates: %v", err)
+		return err
 	}
+	templates = parsed
 
 	templateNames := templates.Templates()
-	log.Printf("Templates chargés (%d): ", len(templateNames))
+	names := make([]string, 0, len(templateNames))
 	for _, t := range templateNames {
-		log.Printf("  - %s", t.Name())
+		names = append(names, t.Name())
 	}
+	slog.Info("Templates chargés", "count", len(names), "names", names)
+	return nil
 }
 
 func main() {
 
-	err := os.MkdirAll("data", 0755)
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(configCommand(os.Args[2:]))
+	}
+
+	cfg, _, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Configuration invalide:\n%v", err)
+	}
+	appConfig = cfg
+	setupLogging(appConfig)
+
+	if err := loadTemplates(appConfig.Templates); err != nil {
+		slog.Error("Erreur fatale lors du chargement des templates", "err", err)
+		os.Exit(1)
+	}
+
+	err = os.MkdirAll(appConfig.DataDir, 0755)
 	if err != nil {
-		log.Printf("Erreur lors de la création du dossier data: %v", err)
+		slog.Error("Erreur lors de la création du dossier de données", "dir", appConfig.DataDir, "err", err)
 	}
 
-	err = os.MkdirAll("static/css", 0755)
+	err = os.MkdirAll(filepath.Join(appConfig.StaticDir, "css"), 0755)
 	if err != nil {
-		log.Printf("Erreur lors de la création des dossiers static/css: %v", err)
+		slog.Error("Erreur lors de la création des dossiers CSS", "dir", appConfig.StaticDir, "err", err)
 	}
 
-	cssPath := filepath.Join("static", "css", "style.css")
+	cssPath := filepath.Join(appConfig.StaticDir, "css", "style.css")
 	if _, err := os.Stat(cssPath); os.IsNotExist(err) {
-		log.Printf("ATTENTION: Le fichier CSS n'existe pas à l'emplacement: %s", cssPath)
+		slog.Warn("Le fichier CSS n'existe pas", "path", cssPath)
 
 		cssContent := `
 		body { font-family: Arial, sans-serif; margin: 0; padding: 0; background-color: #f0f0f0; }
@@ -162,20 +210,25 @@ func main() {
 
 		err = os.WriteFile(cssPath, []byte(cssContent), 0644)
 		if err != nil {
-			log.Printf("Erreur lors de la création du CSS minimal: %v", err)
+			slog.Error("Erreur lors de la création du CSS minimal", "err", err)
 		} else {
-			log.Printf("CSS minimal créé à %s", cssPath)
+			slog.Info("CSS minimal créé", "path", cssPath)
 		}
 	} else {
-		log.Printf("CSS trouvé: %s", cssPath)
+		slog.Debug("CSS trouvé", "path", cssPath)
+	}
+
+	if path := appConfig.PricesFile; path != "" {
+		priceSource = recordedPriceSource{path: path}
+		slog.Info("Prix lus depuis un enregistrement", "path", path)
 	}
+	alertNotifier = notifierFromConfig(appConfig)
+	upstreamBreaker = newCircuitBreaker(appConfig.BreakerThreshold, time.Duration(appConfig.BreakerCooldown))
+	clientLimiter = clientLimiterFromConfig(appConfig)
+	upstreamLimiter = newUpstreamLimiter(appConfig.UpstreamRate, appConfig.UpstreamBackgroundRate, time.Duration(appConfig.UpstreamQueueTimeout))
 
-	fs := http.FileServer(http.Dir("static"))
-	http.Handle("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		log.Printf("Requête de fichier statique: %s", path)
-		http.StripPrefix("/static/", fs).ServeHTTP(w, r)
-	}))
+	fs := http.FileServer(http.Dir(appConfig.StaticDir))
+	http.Handle("/static/", http.StripPrefix("/static/", fs))
 
 	http.HandleFunc("/", homeHandler)
 	http.HandleFunc("/cards", cardsHandler)
@@ -184,86 +237,191 @@ func main() {
 	http.HandleFunc("/set/", setDetailHandler)
 	http.HandleFunc("/search", searchHandler)
 	http.HandleFunc("/favorites", favoritesHandler)
+	http.HandleFunc("/favorites/export", exportHandler)
+	http.HandleFunc("/favorites/import", importHandler)
 	http.HandleFunc("/api/favorite/add/", addFavoriteHandler)
 	http.HandleFunc("/api/favorite/remove/", removeFavoriteHandler)
 	http.HandleFunc("/about", aboutHandler)
 	http.HandleFunc("/test-images", testImagesHandler)
 	http.HandleFunc("/api/favorite/clear", clearFavoritesHandler)
-
-	port := "8080"
-	log.Printf("Serveur démarré sur le port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	http.HandleFunc("/lists", listsHandler)
+	http.HandleFunc("/lists/", listHandler)
+	http.HandleFunc("/api/list/", listAPIHandler)
+	http.HandleFunc("/collection", collectionHandler)
+	http.HandleFunc("/collection/", collectionHandler)
+	http.HandleFunc("/decks", decksHandler)
+	http.HandleFunc("/decks/", deckHandler)
+	http.HandleFunc("/analytics", analyticsHandler)
+	http.HandleFunc("/build", buildCheckHandler)
+	http.HandleFunc("/value", valueHandler)
+	http.HandleFunc("/alerts", alertsHandler)
+	http.HandleFunc("/alerts/", alertsHandler)
+	http.HandleFunc("/stats", statsHandler)
+	http.HandleFunc("/stats/", statsHandler)
+	http.HandleFunc("/artists", artistsHandler)
+	http.HandleFunc("/artist/", artistHandler)
+	http.HandleFunc("/pokemon/", pokemonHandler)
+	http.HandleFunc("/health", healthHandler)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              ":" + appConfig.Port,
+		ReadHeaderTimeout: time.Duration(appConfig.ReadTimeout),
+		ReadTimeout:       time.Duration(appConfig.ReadTimeout),
+		WriteTimeout:      time.Duration(appConfig.WriteTimeout),
+		IdleTimeout:       2 * time.Minute,
+		Handler:           requestIDMiddleware(accessLogMiddleware(clientLimiter.Middleware(http.DefaultServeMux))),
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Serveur démarré", "port", appConfig.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	lifecycle.Start(clientLimiter.evictionJob(rateLimitEvictionInterval))
+	lifecycle.Start(catalogueIndexJob(time.Duration(appConfig.CatalogueIndexTTL)))
+	lifecycle.Start(priceSnapshotJob(time.Duration(appConfig.PriceSnapshotInterval)))
+
+	failed := false
+	select {
+	case err := <-serverErr:
+		slog.Error("Erreur du serveur", "err", err)
+		failed = true
+	case <-ctx.Done():
+		slog.Info("Signal d'arrêt reçu, arrêt en cours")
+	}
+	stop()
+
+	// Les requêtes en cours se terminent avant l'arrêt des tâches de fond, qui peuvent encore
+	// écrire dans les fichiers de données.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(appConfig.ShutdownTimeout))
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Arrêt du serveur incomplet", "err", err)
+	}
+	if err := lifecycle.Stop(shutdownCtx); err != nil {
+		slog.Warn("Arrêt des tâches de fond incomplet", "err", err)
+	}
+	slog.Info("Serveur arrêté")
+	if failed {
+		os.Exit(1)
+	}
 }
 
-func fetchJSON(apiURL string, target interface{}) error {
-
-	client := &http.Client{
-		Timeout: 20 * time.Second,
+// fetchJSON récupère et décode une ressource de l'API selon la politique de nouvelles tentatives.
+// Les appels simultanés à la même URL sont regroupés ; la requête partagée s'arrête, tentatives
+// comprises, dès que tous ses appelants ont abandonné, par exemple en fermant le navigateur.
+func fetchJSON(ctx context.Context, apiURL string, target interface{}) error {
+	body, err := upstreamFlights.Do(ctx, apiURL, fetchBody(apiURL))
+	if err != nil {
+		return err
 	}
 
-	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
+	if err := json.Unmarshal(body, target); err != nil {
+		return permanent(fmt.Errorf("réponse API illisible: %w", err))
+	}
+	return nil
+}
 
-		resp, err := client.Get(apiURL)
-		if err != nil {
-			lastErr = err
-			log.Printf("Tentative d'API %d échouée: %v", attempt+1, err)
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-			continue
+// fetchBody renvoie la fonction qui récupère le corps de apiURL, avec nouvelles tentatives et disjoncteur.
+func fetchBody(apiURL string) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		client := &http.Client{
+			Timeout: time.Duration(appConfig.HTTPTimeout),
 		}
 
-		defer resp.Body.Close()
+		var body []byte
+		err := withRetry(ctx, retryPolicy(), "requête API", func(ctx context.Context) error {
+			if err := upstreamLimiter.Wait(ctx); err != nil {
+				return permanent(err)
+			}
+			if err := upstreamBreaker.Allow(); err != nil {
+				return permanent(err)
+			}
+			var err error
+			body, err = fetchJSONOnce(ctx, client, apiURL)
+			upstreamBreaker.Record(err)
+			return err
+		})
+		return body, err
+	}
+}
 
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("API a retourné le code %d", resp.StatusCode)
-			log.Printf("Tentative d'API %d échouée: %v", attempt+1, lastErr)
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-			continue
-		}
+// fetchJSONOnce effectue une seule tentative et renvoie le corps de la réponse. Le corps est toujours
+// fermé avant le retour ; les erreurs 4xx (hors 408 et 429) et les réponses qui ne sont pas du JSON
+// sont définitives.
+func fetchJSONOnce(ctx context.Context, client *http.Client, apiURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, permanent(err)
+	}
 
-		var bodyBytes []byte
-		bodyBytes, err = io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = err
-			log.Printf("Lecture de la réponse API tentative %d échouée: %v", attempt+1, err)
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-			continue
-		}
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
 
-		if len(bodyBytes) < 500 {
-			log.Printf("Réponse API: %s", string(bodyBytes))
-		} else {
-			log.Printf("Réponse API (tronquée): %s...", string(bodyBytes[:500]))
-		}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.WarnContext(ctx, "Appel API échoué", "url", apiURL, "latency", time.Since(start), "err", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "Appel API", "url", apiURL, "status", resp.StatusCode, "latency", time.Since(start), "priority", priorityOf(ctx).String())
 
-		err = json.Unmarshal(bodyBytes, target)
-		if err != nil {
-			lastErr = err
-			log.Printf("Parsing JSON tentative %d échouée: %v", attempt+1, err)
+	if resp.StatusCode != http.StatusOK {
+		// Le corps est lu pour que la connexion puisse être réutilisée.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, &apiStatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
 
-			if len(bodyBytes) > 200 {
-				log.Printf("Aperçu du corps de la réponse: %s...", bodyBytes[:200])
-			} else {
-				log.Printf("Corps de la réponse: %s", bodyBytes)
-			}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("lecture de la réponse API: %w", err)
+	}
 
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-			continue
-		}
+	// Le corps n'est copié dans le journal qu'au niveau debug.
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		slog.DebugContext(ctx, "Réponse API", "url", apiURL, "size", len(bodyBytes), "body", string(bodyBytes[:minInt(len(bodyBytes), 500)]))
+	}
 
-		return nil
+	if !json.Valid(bodyBytes) {
+		slog.ErrorContext(ctx, "Réponse API illisible", "url", apiURL, "size", len(bodyBytes), "preview", string(bodyBytes[:minInt(len(bodyBytes), 200)]))
+		return nil, permanent(errors.New("réponse API illisible: ce n'est pas du JSON"))
 	}
 
-	return fmt.Errorf("toutes les tentatives de requête API ont échoué, dernière erreur: %v", lastErr)
+	return bodyBytes, nil
+}
+
+// apiStatusError est renvoyée lorsque l'API répond avec un code HTTP autre que 200.
+// RetryAfter reprend l'attente demandée par l'en-tête Retry-After, le cas échéant.
+type apiStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("API a retourné le code %d", e.StatusCode)
 }
 
-func fetchCards(page, limit int, filters map[string]string) ([]Card, int, error) {
-	baseURL := "https://api.tcgdex.net/v2/en/cards"
+// isNotFound indique si l'API ne connaît pas (ou plus) la ressource demandée.
+func isNotFound(err error) bool {
+	var statusErr *apiStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
 
-	log.Printf("Requête de cartes URL: %s", baseURL)
+func fetchCards(ctx context.Context, page, limit int, filters map[string]string) ([]Card, int, error) {
+	baseURL := tcgdexURL("/cards")
+
+	slog.DebugContext(ctx, "Requête de cartes", "url", baseURL)
 
 	var cards []Card
-	err := fetchJSON(baseURL, &cards)
+	err := fetchJSONWithFallback(ctx, baseURL, &cards)
 	if err != nil {
 		return []Card{}, 0, err
 	}
@@ -355,10 +513,10 @@ func fetchCards(page, limit int, filters map[string]string) ([]Card, int, error)
 	return pagedCards, total, nil
 }
 
-func fetchCard(id string) (Card, error) {
-	apiURL := fmt.Sprintf("https://api.tcgdex.net/v2/en/cards/%s", id)
+func fetchCard(ctx context.Context, id string) (Card, error) {
+	apiURL := tcgdexURL("/cards/%s", id)
 	var card Card
-	err := fetchJSON(apiURL, &card)
+	err := fetchJSON(ctx, apiURL, &card)
 
 	if card.Image != "" {
 		if !strings.HasSuffix(card.Image, ".png") && !strings.HasSuffix(card.Image, ".jpg") {
@@ -389,12 +547,12 @@ func (c Card) GetHP() string {
 	}
 }
 
-func fetchSets() ([]Set, error) {
-	apiURL := "https://api.tcgdex.net/v2/en/sets"
-	log.Printf("Requête des sets URL: %s", apiURL)
+func fetchSets(ctx context.Context) ([]Set, error) {
+	apiURL := tcgdexURL("/sets")
+	slog.DebugContext(ctx, "Requête des sets", "url", apiURL)
 
 	var sets []Set
-	err := fetchJSON(apiURL, &sets)
+	err := fetchJSONWithFallback(ctx, apiURL, &sets)
 
 	if err != nil {
 		return []Set{}, err
@@ -417,14 +575,14 @@ func fetchSets() ([]Set, error) {
 		}
 	}
 
-	log.Printf("Nombre de sets récupérés: %d", len(sets))
+	slog.DebugContext(ctx, "Sets récupérés", "count", len(sets))
 	return sets, nil
 }
 
-func fetchSet(id string) (Set, error) {
-	apiURL := fmt.Sprintf("https://api.tcgdex.net/v2/en/sets/%s", id)
+func fetchSet(ctx context.Context, id string) (Set, error) {
+	apiURL := tcgdexURL("/sets/%s", id)
 	var set Set
-	err := fetchJSON(apiURL, &set)
+	err := fetchJSON(ctx, apiURL, &set)
 
 	if set.Logo != "" && !strings.HasSuffix(set.Logo, ".png") && !strings.HasSuffix(set.Logo, ".jpg") {
 		set.Logo = set.Logo + ".png"
@@ -443,14 +601,14 @@ func fetchSet(id string) (Set, error) {
 	return set, err
 }
 
-func fetchTypes() ([]string, error) {
+func fetchTypes(ctx context.Context) ([]string, error) {
 
 	var types []string
-	apiURL := "https://api.tcgdex.net/v2/en/types"
-	err := fetchJSON(apiURL, &types)
+	apiURL := tcgdexURL("/types")
+	err := fetchJSON(ctx, apiURL, &types)
 
 	if err != nil || len(types) == 0 {
-		log.Printf("Utilisation de la liste de secours pour les types: %v", err)
+		slog.WarnContext(ctx, "Utilisation de la liste de secours pour les types", "err", err)
 		return []string{
 			"Colorless", "Darkness", "Dragon", "Fairy", "Fighting",
 			"Fire", "Grass", "Lightning", "Metal", "Psychic", "Water",
@@ -460,14 +618,14 @@ func fetchTypes() ([]string, error) {
 	return types, nil
 }
 
-func fetchRarities() ([]string, error) {
+func fetchRarities(ctx context.Context) ([]string, error) {
 
 	var rarities []string
-	apiURL := "https://api.tcgdex.net/v2/en/rarities"
-	err := fetchJSON(apiURL, &rarities)
+	apiURL := tcgdexURL("/rarities")
+	err := fetchJSON(ctx, apiURL, &rarities)
 
 	if err != nil || len(rarities) == 0 {
-		log.Printf("Utilisation de la liste de secours pour les raretés: %v", err)
+		slog.WarnContext(ctx, "Utilisation de la liste de secours pour les raretés", "err", err)
 		return []string{
 			"Common", "Uncommon", "Rare", "Rare Holo", "Rare Ultra",
 			"Rare Holo EX", "Rare Holo GX", "Rare Holo V", "Rare Holo VMAX",
@@ -478,62 +636,25 @@ func fetchRarities() ([]string, error) {
 	return rarities, nil
 }
 
-func loadFavorites() (Favorites, error) {
-	var favorites Favorites
-	favorites.Cards = []Card{} // Initialize with empty array
-
-	// Check if file exists
-	_, err := os.Stat("data/favorites.json")
-	if os.IsNotExist(err) {
-
-		err = saveFavorites(favorites)
-		if err != nil {
-			return favorites, err
-		}
-		return favorites, nil
-	}
-
-	data, err := os.ReadFile("data/favorites.json")
-	if err != nil {
-		return favorites, err
-	}
-
-	if len(data) == 0 || string(data) == "" {
-		return favorites, nil
-	}
-
-	err = json.Unmarshal(data, &favorites)
-	if err != nil {
-		log.Printf("Erreur de parsing du fichier de favoris, création d'un nouveau fichier: %v", err)
-		newFavorites := Favorites{Cards: []Card{}}
-		saveFavorites(newFavorites)
-		return newFavorites, nil
-	}
-
-	return favorites, nil
-}
-
-func saveFavorites(favorites Favorites) error {
-
-	os.MkdirAll("data", 0755)
-
-	data, err := json.Marshal(favorites)
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile("data/favorites.json", data, 0644)
-}
-
 func homeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	if r.URL.Path != "/" {
 		showError(w, "Page non trouvée", fmt.Errorf("URL invalide: %s", r.URL.Path))
 		return
 	}
 
-	cards, _, err := fetchCards(1, 6, nil)
-
-	sets, err2 := fetchSets()
+	var cards []Card
+	var sets []Set
+	group := newFetchGroup(ctx, pageFetchWorkers)
+	group.Go("cartes", func(ctx context.Context) (err error) {
+		cards, _, err = fetchCards(ctx, 1, 6, nil)
+		return err
+	})
+	group.Go("sets", func(ctx context.Context) (err error) {
+		sets, err = fetchSets(ctx)
+		return err
+	})
+	group.Wait()
 
 	data := struct {
 		RecentCards []Card
@@ -544,13 +665,13 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 		Sets:        sets,
 	}
 
-	if err != nil {
-		log.Printf("Erreur lors de la récupération des cartes: %v", err)
+	if err := group.Err("cartes"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des cartes", "err", err)
 		data.Error = "Impossible de charger les cartes récentes."
 	}
 
-	if err2 != nil {
-		log.Printf("Erreur lors de la récupération des sets: %v", err2)
+	if err := group.Err("sets"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des sets", "err", err)
 		if data.Error != "" {
 			data.Error += " "
 		}
@@ -562,12 +683,13 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := templates.ExecuteTemplate(w, "index.html", data); err != nil {
-		log.Printf("Erreur de rendu du template index.html: %v", err)
+		slog.ErrorContext(ctx, "Erreur de rendu du template", "template", "index.html", "err", err)
 		showError(w, "Erreur d'affichage de la page d'accueil", err)
 	}
 }
 
 func cardsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	r.ParseForm()
 	page, _ := strconv.Atoi(r.FormValue("page"))
@@ -611,9 +733,32 @@ func cardsHandler(w http.ResponseWriter, r *http.Request) {
 		Total:    0,
 	}
 
-	cards, total, err := fetchCards(page, limit, filters)
-	if err != nil {
-		log.Printf("Erreur lors de la récupération des cartes: %v", err)
+	// Les cartes et les options des filtres ne dépendent pas les unes des autres.
+	var cards []Card
+	var total int
+	var types, rarities []string
+	var sets []Set
+	group := newFetchGroup(ctx, pageFetchWorkers)
+	group.Go("cartes", func(ctx context.Context) (err error) {
+		cards, total, err = fetchCards(ctx, page, limit, filters)
+		return err
+	})
+	group.Go("types", func(ctx context.Context) (err error) {
+		types, err = fetchTypes(ctx)
+		return err
+	})
+	group.Go("raretés", func(ctx context.Context) (err error) {
+		rarities, err = fetchRarities(ctx)
+		return err
+	})
+	group.Go("sets", func(ctx context.Context) (err error) {
+		sets, err = fetchSets(ctx)
+		return err
+	})
+	group.Wait()
+
+	if err := group.Err("cartes"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des cartes", "err", err)
 		data.Error = "Impossible de récupérer les cartes. Veuillez réessayer plus tard."
 	} else {
 		data.Cards = cards
@@ -637,53 +782,71 @@ func cardsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	types, err := fetchTypes()
-	if err != nil {
-		log.Printf("Erreur lors de la récupération des types: %v", err)
+	if err := group.Err("types"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des types", "err", err)
 	} else {
 		data.Types = types
 	}
 
-	rarities, err := fetchRarities()
-	if err != nil {
-		log.Printf("Erreur lors de la récupération des raretés: %v", err)
+	if err := group.Err("raretés"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des raretés", "err", err)
 	} else {
 		data.Rarities = rarities
 	}
 
-	sets, err := fetchSets()
-	if err != nil {
-		log.Printf("Erreur lors de la récupération des sets: %v", err)
+	if err := group.Err("sets"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des sets", "err", err)
 	} else {
 		data.Sets = sets
 	}
 
 	if err := templates.ExecuteTemplate(w, "cards.html", data); err != nil {
-		log.Printf("Erreur de rendu du template cards.html: %v", err)
+		slog.ErrorContext(ctx, "Erreur de rendu du template", "template", "cards.html", "err", err)
 		showError(w, "Erreur d'affichage de la liste des cartes", err)
 	}
 }
 
 func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/card/")
+	ctx := r.Context()
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/card/"), "/")
 	if id == "" {
 		showError(w, "Page non trouvée", fmt.Errorf("ID de carte non spécifié"))
 		return
 	}
 
-	card, err := fetchCard(id)
+	switch action {
+	case "":
+	case "prices":
+		priceHistoryHandler(w, r, id)
+		return
+	default:
+		showError(w, "Page non trouvée", fmt.Errorf("URL invalide: %s", r.URL.Path))
+		return
+	}
+
+	card, err := getCard(ctx, id)
 	if err != nil {
 		showError(w, "Impossible de récupérer les détails de la carte", err)
 		return
 	}
 
 	favorites, _ := loadFavorites()
-	isFavorite := false
-	for _, favCard := range favorites.Cards {
-		if favCard.ID == card.ID {
-			isFavorite = true
-			break
-		}
+	isFavorite := favorites.FavoritesList().Contains(card.ID)
+	memberships := favorites.ListsContaining(card.ID)
+
+	collection, err := loadCollection()
+	if err != nil {
+		slog.ErrorContext(ctx, "Erreur lors du chargement de la collection", "err", err)
+	}
+
+	if pricing, err := priceSource.Prices(ctx, []string{card.ID}); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des prix", "card", card.ID, "err", err)
+	} else {
+		card.Pricing = pricing[card.ID]
+	}
+	prices, err := loadPrices()
+	if err != nil {
+		slog.ErrorContext(ctx, "Erreur lors du chargement de l'historique des prix", "err", err)
 	}
 
 	html := `<!DOCTYPE html>
@@ -704,6 +867,10 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -713,6 +880,7 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="card-detail fade-in">
@@ -727,6 +895,29 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
 		html += `<button id="add-favorite" data-id="` + card.ID + `" class="button">Ajouter aux Favoris</button>`
 	}
 
+	html += `</div>
+                
+                <div class="list-controls">`
+
+	if len(memberships) > 0 {
+		html += `<div class="list-badges">`
+		for _, list := range memberships {
+			html += `<a class="list-badge" href="/lists/` + list.Slug + `">` + escape(list.Name) + `</a>`
+		}
+		html += `</div>`
+	}
+
+	html += `<select id="add-to-list" data-id="` + card.ID + `">
+                        <option value="">Ajouter à une liste...</option>`
+	for _, list := range favorites.Lists {
+		if !list.Contains(card.ID) {
+			html += `<option value="` + list.Slug + `">` + escape(list.Name) + `</option>`
+		}
+	}
+	html += `</select>
+                ` + renderDeckPicker(card.ID) + `
+                </div>`
+
 	html += `</div>
             </div>
             
@@ -763,21 +954,24 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
                 </p>`
 	}
 
-	if card.Artist != "" {
-		html += `<p><strong>Illustrateur:</strong> ` + card.Artist + `</p>`
-	} else if card.Illustrator != "" {
-		html += `<p><strong>Illustrateur:</strong> ` + card.Illustrator + `</p>`
+	if illustrator := cardIllustrator(card); illustrator != "" {
+		html += `<p><strong>Illustrateur:</strong> <a href="` + artistURL(illustrator) + `">` + escape(illustrator) + `</a></p>`
 	}
 
 	if card.Category != "" {
 		html += `<p><strong>Catégorie:</strong> ` + card.Category + `</p>`
 	}
 
+	for _, dexID := range card.DexID {
+		html += `<p><strong>Pokédex:</strong> <a href="/pokemon/` + strconv.Itoa(dexID) + `">n°` + strconv.Itoa(dexID) + `</a></p>`
+	}
+
 	if card.RegulationMark != "" {
 		html += `<p><strong>Régulation:</strong> ` + card.RegulationMark + `</p>`
 	}
 
 	html += `</div>
+` + renderPriceSection(card, prices.History(card.ID), requestCurrency(r)) + `
                 
                 <div class="card-actions">
                     <a href="/cards" class="button secondary">Retour aux Cartes</a>
@@ -785,6 +979,7 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
                 </div>
             </div>
         </div>
+` + renderOtherVersions(card) + renderOwnedSection(card, collection.ForCard(card.ID)) + `
     </main>
     
     <footer>
@@ -797,6 +992,7 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
         document.addEventListener('DOMContentLoaded', function() {
             const addButton = document.getElementById('add-favorite');
             const removeButton = document.getElementById('remove-favorite');
+            const listSelect = document.getElementById('add-to-list');
             
             if (addButton) {
                 addButton.addEventListener('click', function() {
@@ -821,6 +1017,21 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
                         });
                 });
             }
+            
+            if (listSelect) {
+                listSelect.addEventListener('change', function() {
+                    if (!this.value) {
+                        return;
+                    }
+                    const cardId = this.getAttribute('data-id');
+                    fetch('/api/list/' + encodeURIComponent(this.value) + '/add/' + cardId)
+                        .then(response => {
+                            if (response.ok) {
+                                window.location.reload();
+                            }
+                        });
+                });
+            }
         });
     </script>
 </body>
@@ -831,13 +1042,20 @@ func cardDetailHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func setsHandler(w http.ResponseWriter, r *http.Request) {
-	sets, err := fetchSets()
+	ctx := r.Context()
+	sets, err := fetchSets(ctx)
 
 	if err != nil {
 		showError(w, "Impossible de récupérer la liste des collections", err)
 		return
 	}
 
+	collection, err := loadCollection()
+	if err != nil {
+		slog.ErrorContext(ctx, "Erreur lors du chargement de la collection", "err", err)
+	}
+	owned := ownedBySet(collection)
+
 	html := `<!DOCTYPE html>
 <html lang="fr">
 <head>
@@ -856,6 +1074,10 @@ func setsHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -865,6 +1087,7 @@ func setsHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="page-header">
@@ -902,6 +1125,10 @@ func setsHandler(w http.ResponseWriter, r *http.Request) {
 				html += `<p class="release-date">Date de sortie: ` + set.ReleaseDate + `</p>`
 			}
 
+			if len(owned[set.ID]) > 0 {
+				html += renderCompletion(computeSetCompletion(set, owned[set.ID]))
+			}
+
 			html += `</div>
                 </a>
             </div>`
@@ -924,10 +1151,10 @@ func setsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write([]byte(html))
 }
-func fetchSetCards(setID string, limit int) ([]Card, error) {
+func fetchSetCards(ctx context.Context, setID string, limit int) ([]Card, error) {
 
-	apiURL := fmt.Sprintf("https://api.tcgdex.net/v2/en/sets/%s", setID)
-	log.Printf("Requête des cartes du set %s à l'URL: %s", setID, apiURL)
+	apiURL := tcgdexURL("/sets/%s", setID)
+	slog.DebugContext(ctx, "Requête des cartes d'un set", "set", setID, "url", apiURL)
 
 	type SetResponse struct {
 		CardCount struct {
@@ -937,12 +1164,12 @@ func fetchSetCards(setID string, limit int) ([]Card, error) {
 	}
 
 	var setData SetResponse
-	err := fetchJSON(apiURL, &setData)
+	err := fetchJSONWithFallback(ctx, apiURL, &setData)
 	if err != nil {
 		return []Card{}, err
 	}
 
-	log.Printf("Nombre de cartes trouvées pour le set %s: %d", setID, len(setData.Cards))
+	slog.DebugContext(ctx, "Cartes du set récupérées", "set", setID, "count", len(setData.Cards))
 
 	for i := range setData.Cards {
 
@@ -969,22 +1196,57 @@ func fetchSetCards(setID string, limit int) ([]Card, error) {
 	return setData.Cards, nil
 }
 func setDetailHandler(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/set/")
+	ctx := r.Context()
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/set/"), "/")
 	if id == "" {
 		showError(w, "Page non trouvée", fmt.Errorf("ID de set non spécifié"))
 		return
 	}
 
-	set, err := fetchSet(id)
-	if err != nil {
+	switch action {
+	case "":
+	case "checklist":
+		setChecklistHandler(w, r, id)
+		return
+	case "open":
+		boosterHandler(w, r, id)
+		return
+	default:
+		showError(w, "Page non trouvée", fmt.Errorf("URL invalide: %s", r.URL.Path))
+		return
+	}
+
+	var set Set
+	var cards []Card
+	group := newFetchGroup(ctx, pageFetchWorkers)
+	group.Go("set", func(ctx context.Context) (err error) {
+		set, err = fetchSet(ctx, id)
+		return err
+	})
+	group.Go("cartes", func(ctx context.Context) (err error) {
+		cards, err = fetchSetCards(ctx, id, 0)
+		return err
+	})
+	group.Wait()
+
+	if err := group.Err("set"); err != nil {
 		showError(w, "Impossible de récupérer les détails de la collection", err)
 		return
 	}
+	if err := group.Err("cartes"); err != nil {
+		slog.ErrorContext(ctx, "Erreur lors de la récupération des cartes du set", "set", id, "err", err)
+	}
 
-	cards, err := fetchSetCards(id, 100)
+	collection, err := loadCollection()
 	if err != nil {
+		slog.ErrorContext(ctx, "Erreur lors du chargement de la collection", "err", err)
+	}
+	owned := ownedBySet(collection)[id]
+	completion := computeSetCompletion(set, owned)
 
-		log.Printf("Erreur lors de la récupération des cartes du set: %v", err)
+	missingOnly := r.FormValue("missing") == "1"
+	if missingOnly {
+		cards = missingCards(cards, owned)
 	}
 
 	html := `<!DOCTYPE html>
@@ -1005,6 +1267,10 @@ func setDetailHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -1014,6 +1280,7 @@ func setDetailHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="set-detail">
@@ -1032,17 +1299,39 @@ func setDetailHandler(w http.ResponseWriter, r *http.Request) {
 		html += `<p><strong>Date de sortie:</strong> ` + set.ReleaseDate + `</p>`
 	}
 
+	html += renderCompletion(completion)
+
 	html += `</div>
             </div>
             
             <div class="set-cards">
                 <h3>Cartes de cette collection</h3>
+                <div class="set-filters">`
+
+	if missingOnly {
+		html += `<a href="/set/` + id + `" class="button outline">Toutes les cartes</a>
+                    <span class="button secondary">Manquantes uniquement</span>`
+	} else {
+		html += `<span class="button secondary">Toutes les cartes</span>
+                    <a href="/set/` + id + `?missing=1" class="button outline">Manquantes uniquement</a>`
+	}
+
+	html += `
+                    <a href="/set/` + id + `/checklist" class="button">Liste des manquantes</a>
+                    <a href="/set/` + id + `/open" class="button">Ouvrir des boosters</a>
+                </div>
                 <div class="card-grid fade-in">`
 
 	if len(cards) > 0 {
 		for _, card := range cards {
-			html += `<div class="card">
+			status, badge := "missing", `<span class="owned-badge missing">Manquante</span>`
+			if quantity := owned[card.LocalId]; quantity > 0 {
+				status, badge = "owned", `<span class="owned-badge">× `+strconv.Itoa(quantity)+`</span>`
+			}
+
+			html += `<div class="card ` + status + `">
                     <a href="/card/` + card.ID + `">
+                        ` + badge + `
                         <img src="` + card.Image + `" alt="` + card.Name + `">
                         <div class="card-content">
                             <h4>` + card.Name + `</h4>
@@ -1060,6 +1349,8 @@ func setDetailHandler(w http.ResponseWriter, r *http.Request) {
                     </a>
                 </div>`
 		}
+	} else if missingOnly {
+		html += `<p class="no-results">Vous possédez toutes les cartes de cette collection !</p>`
 	} else {
 		html += `<p class="no-results">Aucune carte n'a pu être chargée pour cette collection.</p>`
 	}
@@ -1264,6 +1555,10 @@ func aboutHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -1273,6 +1568,7 @@ func aboutHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="page-header">
@@ -1587,7 +1883,9 @@ func aboutHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func favoritesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	favorites, err := loadFavorites()
+	list := favorites.FavoritesList()
 
 	html := `<!DOCTYPE html>
 <html lang="fr">
@@ -1607,6 +1905,10 @@ func favoritesHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -1616,58 +1918,42 @@ func favoritesHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="page-header">
             <h2>Mes Cartes Favorites</h2>
         </div>`
 
-	if err != nil {
+	if errors.Is(err, errFavoritesCorrupt) {
 		html += `
         <div class="error-message">
-            <p>Une erreur est survenue lors du chargement des favoris. Vos favoris ont été réinitialisés.</p>
-        </div>
-        
-        <div class="no-favorites">
-            <p>Vous n'avez pas encore de cartes favorites.</p>
-            <p>Parcourez les <a href="/cards">cartes</a> et ajoutez-en à vos favoris.</p>
+            <p>Le fichier de favoris est illisible ; il a été laissé tel quel. À la prochaine modification d'une liste,
+            il sera mis de côté dans <code>` + escape(favoritesFile) + `.bak</code> et de nouvelles listes vides seront créées.</p>
+        </div>`
+	} else if err != nil {
+		html += `
+        <div class="error-message">
+            <p>Impossible de charger les favoris : ` + escape(err.Error()) + `</p>
         </div>`
-	} else if len(favorites.Cards) > 0 {
+	} else if len(list.Cards) > 0 {
 		html += `
         <div class="favorites-controls">
-            <p>Vous avez ` + strconv.Itoa(len(favorites.Cards)) + ` cartes en favoris</p>
+            <p>Vous avez ` + strconv.Itoa(len(list.Cards)) + ` cartes en favoris</p>
         </div>
 
         <div class="card-grid fade-in">`
 
-		for _, card := range favorites.Cards {
-			html += `
-            <div class="card favorite-card">
-                <a href="/card/` + card.ID + `">
-                    <img src="` + card.Image + `" alt="` + card.Name + `">
-                    <div class="card-content">
-                        <h3>` + card.Name + `</h3>
-                        <p>` + card.Set.Name + `</p>`
-
-			if len(card.Types) > 0 {
-				html += `<div class="card-types">`
-				for _, cardType := range card.Types {
-					html += `<span class="type ` + cardType + `">` + cardType + `</span>`
-				}
-				html += `</div>`
-			}
-
-			html += `
-                    </div>
-                </a>
-                <button class="remove-favorite" data-id="` + card.ID + `">Retirer</button>
-            </div>`
+		for _, fav := range hydrateCards(ctx, list.IDs()) {
+			html += renderCardTile(fav, `<button class="remove-favorite" data-id="`+escape(fav.ID)+`">Retirer</button>`)
 		}
 
 		html += `
         </div>
 
         <div class="favorites-actions">
+            <a href="/favorites/import" class="button secondary">Importer / Exporter</a>
+            <a href="/analytics?list=favorites" class="button secondary">Statistiques</a>
             <button id="clear-favorites" class="button">Vider ma liste de favoris</button>
         </div>`
 	} else {
@@ -1728,37 +2014,15 @@ func favoritesHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func addFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	cardID := strings.TrimPrefix(r.URL.Path, "/api/favorite/add/")
 	if cardID == "" {
 		http.Error(w, "ID de carte requis", http.StatusBadRequest)
 		return
 	}
 
-	card, err := fetchCard(cardID)
-	if err != nil {
-		http.Error(w, "Impossible de récupérer la carte: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	favorites, err := loadFavorites()
-	if err != nil {
-		http.Error(w, "Impossible de charger les favoris: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	for _, favCard := range favorites.Cards {
-		if favCard.ID == card.ID {
-
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-	}
-
-	favorites.Cards = append(favorites.Cards, card)
-
-	err = saveFavorites(favorites)
-	if err != nil {
-		http.Error(w, "Impossible de sauvegarder les favoris: "+err.Error(), http.StatusInternalServerError)
+	if status, err := applyListAction(ctx, favoritesListSlug, "add", cardID, ""); err != nil {
+		http.Error(w, "Impossible d'ajouter la carte aux favoris: "+err.Error(), status)
 		return
 	}
 
@@ -1766,46 +2030,32 @@ func addFavoriteHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func removeFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	cardID := strings.TrimPrefix(r.URL.Path, "/api/favorite/remove/")
 	if cardID == "" {
 		http.Error(w, "ID de carte requis", http.StatusBadRequest)
 		return
 	}
 
-	favorites, err := loadFavorites()
-	if err != nil {
-		http.Error(w, "Impossible de charger les favoris: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	for i, card := range favorites.Cards {
-		if card.ID == cardID {
-			favorites.Cards = append(favorites.Cards[:i], favorites.Cards[i+1:]...)
-			break
-		}
-	}
-
-	err = saveFavorites(favorites)
-	if err != nil {
-		http.Error(w, "Impossible de sauvegarder les favoris: "+err.Error(), http.StatusInternalServerError)
+	if status, err := applyListAction(ctx, favoritesListSlug, "remove", cardID, ""); err != nil {
+		http.Error(w, "Impossible de retirer la carte des favoris: "+err.Error(), status)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 func clearFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	newFavorites := Favorites{Cards: []Card{}}
-	err := saveFavorites(newFavorites)
-
-	if err != nil {
-		http.Error(w, "Impossible de vider les favoris: "+err.Error(), http.StatusInternalServerError)
+	if status, err := applyListAction(ctx, favoritesListSlug, "clear", "", ""); err != nil {
+		http.Error(w, "Impossible de vider les favoris: "+err.Error(), status)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 func searchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	query := r.FormValue("q")
 	if query == "" {
 		http.Redirect(w, r, "/cards", http.StatusSeeOther)
@@ -1813,13 +2063,13 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filters := map[string]string{"name": query}
-	cards, _, err := fetchCards(1, 1000, filters)
+	cards, _, err := fetchCards(ctx, 1, 1000, filters)
 
 	count := len(cards)
 	errorMsg := ""
 
 	if err != nil {
-		log.Printf("Erreur lors de la recherche de cartes: %v", err)
+		slog.ErrorContext(ctx, "Erreur lors de la recherche de cartes", "query", query, "err", err)
 		errorMsg = "Erreur lors de la recherche. Veuillez réessayer plus tard."
 	}
 
@@ -1841,6 +2091,10 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
                     <li><a href="/cards">Cartes</a></li>
                     <li><a href="/sets">Collections</a></li>
                     <li><a href="/favorites">Favoris</a></li>
+                    <li><a href="/lists">Listes</a></li>
+                    <li><a href="/collection">Ma collection</a></li>
+                    <li><a href="/decks">Decks</a></li>
+                    <li><a href="/stats">Statistiques</a></li>
                     <li><a href="/about">À propos</a></li>
                 </ul>
             </nav>
@@ -1850,6 +2104,7 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
             </form>
         </div>
     </header>
+` + degradedBanner() + `
     
     <main class="container">
         <div class="page-header">
@@ -1914,14 +2169,15 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func testImagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	cards, _, err := fetchCards(1, 5, nil)
+	cards, _, err := fetchCards(ctx, 1, 5, nil)
 	if err != nil {
 		http.Error(w, "Erreur lors de la récupération des cartes: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	sets, err := fetchSets()
+	sets, err := fetchSets(ctx)
 	if err != nil || len(sets) == 0 {
 		http.Error(w, "Erreur lors de la récupération des sets: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -2016,15 +2272,35 @@ func renderErrorPage(w http.ResponseWriter, message string, errMsg error) {
 	}
 
 	if renderErr := templates.ExecuteTemplate(w, "error.html", data); renderErr != nil {
-		log.Printf("Erreur de rendu du template d'erreur: %v", renderErr)
+		slog.Error("Erreur de rendu du template", "template", "error.html", "err", renderErr)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.Write([]byte(fmt.Sprintf("%s: %v", message, errMsg)))
 	}
 }
+
+// localRedirect renvoie target s'il désigne une page du site, fallback sinon. Les adresses
+// absolues, celles qui commencent par « // » ou contiennent « \ » (que les navigateurs lisent
+// comme « / ») mèneraient vers un autre site.
+func localRedirect(target, fallback string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.ContainsAny(target, "\\\r\n") {
+		return fallback
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return fallback
+	}
+	return target
+}
+
 func showError(w http.ResponseWriter, title string, errDetail error) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusInternalServerError)
+	if errors.Is(errDetail, errUpstreamUnavailable) || errors.Is(errDetail, errUpstreamBusy) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Duration(appConfig.BreakerCooldown).Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
 
 	html := fmt.Sprintf(`
     <!DOCTYPE html>
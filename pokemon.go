@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mentions de mécanique de jeu retirées en fin de nom pour retrouver le Pokémon de base.
+var pokemonNameSuffixes = []string{
+	"vmax", "vstar", "v-union", "v", "ex", "gx", "break", "lv.x", "prime", "legend", "star", "δ", "◇",
+}
+
+// Préfixes de forme ou de variante retirés en début de nom.
+var pokemonNamePrefixes = []string{
+	"alolan", "galarian", "hisuian", "paldean", "radiant", "shining", "dark", "light", "mega", "m",
+}
+
+// Nombre maximum de cartes différentes affichées dans « Autres versions ».
+const otherVersionsLimit = 12
+
+// Genres de versions d'une carte, du plus proche au plus éloigné.
+const (
+	versionAlternate = "alternate"
+	versionReprint   = "reprint"
+	versionDifferent = "different"
+)
+
+var versionLabels = map[string]string{
+	versionAlternate: "Illustration alternative",
+	versionReprint:   "Réimpression",
+	versionDifferent: "Autre carte",
+}
+
+// pokemonBaseName ramène un nom de carte au nom du Pokémon : « Radiant Charizard »,
+// « Charizard ex » et « Team Rocket's Charizard » donnent tous « charizard ».
+func pokemonBaseName(name string) string {
+	words := strings.Fields(normalizeCardName(name))
+
+	// Les cartes « Dresseur's Pokémon » portent le nom de leur dresseur.
+	for i, word := range words {
+		if strings.HasSuffix(word, "'s") && i < len(words)-1 {
+			words = words[i+1:]
+			break
+		}
+	}
+
+	for changed := true; changed && len(words) > 1; {
+		changed = false
+		for _, prefix := range pokemonNamePrefixes {
+			if words[0] == prefix {
+				words = words[1:]
+				changed = true
+				break
+			}
+		}
+		for _, suffix := range pokemonNameSuffixes {
+			if len(words) > 1 && words[len(words)-1] == suffix {
+				words = words[:len(words)-1]
+				changed = true
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// sharesDexID indique si deux cartes représentent au moins un Pokémon commun.
+func sharesDexID(a, b []int) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// versionGroupKey renvoie la clé qui regroupe les versions d'une carte sans numéro de Pokédex :
+// même catégorie et même nom de base.
+func versionGroupKey(category, name string) string {
+	return category + "/" + pokemonBaseName(name)
+}
+
+// versionLookup retrouve les cartes de l'index par numéro de Pokédex et par clé de groupe,
+// pour ne pas parcourir tout l'index à chaque page de carte. Il est construit avec l'index.
+type versionLookup struct {
+	byDex   map[int][]int
+	byGroup map[string][]int
+}
+
+func newVersionLookup(cards []IndexedCard) *versionLookup {
+	lookup := &versionLookup{byDex: make(map[int][]int), byGroup: make(map[string][]int)}
+	for i, card := range cards {
+		for _, dexID := range card.DexIDs {
+			lookup.byDex[dexID] = append(lookup.byDex[dexID], i)
+		}
+		// Un index d'un ancien format n'a pas de clé de groupe, mais elle se déduit du nom.
+		key := card.GroupKey
+		if key == "" {
+			key = versionGroupKey(card.Category, card.Name)
+		}
+		lookup.byGroup[key] = append(lookup.byGroup[key], i)
+	}
+	return lookup
+}
+
+// sameVersionGroup indique si other est une autre version de card : même numéro de Pokédex,
+// ou à défaut même clé de groupe.
+func sameVersionGroup(card Card, group string, other IndexedCard) bool {
+	if len(card.DexID) > 0 && len(other.DexIDs) > 0 {
+		return sharesDexID(card.DexID, other.DexIDs)
+	}
+	return other.GroupKey == group || other.GroupKey == "" && versionGroupKey(other.Category, other.Name) == group
+}
+
+// CardVersion est une autre impression d'une carte, qualifiée par rapport à celle-ci.
+type CardVersion struct {
+	Card IndexedCard
+	Kind string
+}
+
+// cardVersions renvoie les autres versions de card présentes dans l'index. Une carte de même nom,
+// de même PV et de mêmes attaques (signature conservée dans l'index) est une réimpression, ou une
+// illustration alternative si elle est dans le même set.
+func cardVersions(index CatalogueIndex, card Card) []CardVersion {
+	lookup := index.versions
+	if lookup == nil {
+		lookup = newVersionLookup(index.Cards)
+	}
+
+	group := versionGroupKey(card.Category, card.Name)
+	candidates := append([]int(nil), lookup.byGroup[group]...)
+	for _, dexID := range card.DexID {
+		candidates = append(candidates, lookup.byDex[dexID]...)
+	}
+	sort.Ints(candidates)
+
+	var versions []CardVersion
+	same := make(map[string]bool)
+	name := normalizeCardName(card.Name)
+	hp, _ := strconv.Atoi(card.GetHP())
+	signature := attackSignature(card)
+
+	for i, position := range candidates {
+		other := index.Cards[position]
+		if i > 0 && candidates[i-1] == position || other.ID == card.ID || !sameVersionGroup(card, group, other) {
+			continue
+		}
+		versions = append(versions, CardVersion{Card: other, Kind: versionDifferent})
+		if other.Signature != "" && other.Signature == signature && other.HP == hp && normalizeCardName(other.Name) == name {
+			same[other.ID] = true
+		}
+	}
+
+	for i, version := range versions {
+		switch {
+		case !same[version.Card.ID]:
+		case version.Card.SetID == card.Set.ID:
+			versions[i].Kind = versionAlternate
+		default:
+			versions[i].Kind = versionReprint
+		}
+	}
+
+	sets := indexedSetsByID(index)
+	rank := map[string]int{versionAlternate: 0, versionReprint: 1, versionDifferent: 2}
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if a.Kind != b.Kind {
+			return rank[a.Kind] < rank[b.Kind]
+		}
+		return indexedCardLess(a.Card, b.Card, sets)
+	})
+	return versions
+}
+
+func indexedSetsByID(index CatalogueIndex) map[string]IndexedSet {
+	sets := make(map[string]IndexedSet, len(index.Sets))
+	for _, set := range index.Sets {
+		sets[set.ID] = set
+	}
+	return sets
+}
+
+// indexedCardLess trie les cartes par date de sortie du set, puis par numéro.
+func indexedCardLess(a, b IndexedCard, sets map[string]IndexedSet) bool {
+	if da, db := sets[a.SetID].ReleaseDate, sets[b.SetID].ReleaseDate; da != db {
+		return da < db
+	}
+	if a.SetID != b.SetID {
+		return a.SetID < b.SetID
+	}
+	return localIDLess(a.LocalID, b.LocalID)
+}
+
+// renderVersionTile affiche une version dans la grille, avec son genre et son set.
+func renderVersionTile(version CardVersion, sets map[string]IndexedSet) string {
+	card := version.Card.Card()
+	card.Set.Name = sets[version.Card.SetID].Name
+	return renderCardTile(HydratedCard{ID: card.ID, Card: card}, `<p class="version-kind `+version.Kind+`">`+versionLabels[version.Kind]+`</p>`)
+}
+
+// renderOtherVersions construit la section « Autres versions » de la page d'une carte.
+func renderOtherVersions(card Card) string {
+	index := currentCatalogueIndex()
+	if len(index.Cards) == 0 {
+		return ""
+	}
+
+	versions := cardVersions(index, card)
+	if len(versions) == 0 {
+		return ""
+	}
+	sets := indexedSetsByID(index)
+
+	html := `
+        <section class="other-versions">
+            <h3>Autres versions</h3>`
+	for _, dexID := range card.DexID {
+		html += `
+            <p><a href="/pokemon/` + strconv.Itoa(dexID) + `">Toutes les cartes du Pokémon n°` + strconv.Itoa(dexID) + `</a></p>`
+	}
+	html += `
+            <div class="card-grid">`
+
+	different, hidden := 0, 0
+	for _, version := range versions {
+		if version.Kind == versionDifferent {
+			if different == otherVersionsLimit {
+				hidden++
+				continue
+			}
+			different++
+		}
+		html += renderVersionTile(version, sets)
+	}
+	html += `
+            </div>`
+	if hidden > 0 {
+		html += `
+            <p class="results-count">Et ` + strconv.Itoa(hidden) + ` autre(s) carte(s) de ce Pokémon.</p>`
+	}
+	html += `
+        </section>`
+	return html
+}
+
+// pokemonHandler gère /pokemon/{dexId} : toutes les cartes d'un Pokémon, regroupées par nom de carte
+// (« Charizard », « Charizard ex », « Radiant Charizard »...) et triées par date de sortie.
+func pokemonHandler(w http.ResponseWriter, r *http.Request) {
+	dexID, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/pokemon/"), "/"))
+	if err != nil || dexID <= 0 {
+		showError(w, "Page non trouvée", fmt.Errorf("numéro de Pokédex invalide"))
+		return
+	}
+
+	index := currentCatalogueIndex()
+	if index.UpdatedAt.IsZero() && len(index.Cards) == 0 {
+		renderIndexNotBuilt(w, "Pokémon n°"+strconv.Itoa(dexID))
+		return
+	}
+
+	sets := indexedSetsByID(index)
+	var cards []IndexedCard
+	for _, card := range index.Cards {
+		if sharesDexID(card.DexIDs, []int{dexID}) {
+			cards = append(cards, card)
+		}
+	}
+	if len(cards) == 0 {
+		showError(w, "Pokémon introuvable", fmt.Errorf("aucune carte pour le Pokémon n°%d", dexID))
+		return
+	}
+	sort.SliceStable(cards, func(i, j int) bool { return indexedCardLess(cards[i], cards[j], sets) })
+
+	// Le nom affiché est celui de la carte la plus simple, par exemple « Charizard » plutôt que « Charizard ex ».
+	title := cards[0].Name
+	for _, card := range cards {
+		if len(card.Name) < len(title) {
+			title = card.Name
+		}
+	}
+	if base := pokemonBaseName(title); base != "" {
+		if i := strings.Index(strings.ToLower(title), base); i >= 0 {
+			title = title[i : i+len(base)]
+		}
+	}
+
+	groups := make(map[string][]IndexedCard)
+	var names []string
+	setCount := make(map[string]bool)
+	for _, card := range cards {
+		name := normalizeCardName(card.Name)
+		if groups[name] == nil {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], card)
+		setCount[card.SetID] = true
+	}
+
+	first, last := sets[cards[0].SetID].Year(), sets[cards[len(cards)-1].SetID].Year()
+
+	html := `
+        <div class="page-header">
+            <h2>` + escape(title) + ` <span class="results-count">Pokédex n°` + strconv.Itoa(dexID) + `</span></h2>
+            <p class="results-count">` + strconv.Itoa(len(cards)) + ` cartes dans ` + strconv.Itoa(len(setCount)) + ` sets, ` + strconv.Itoa(len(names)) + ` cartes différentes`
+	if first != "" && last != "" {
+		html += ` — de ` + first + ` à ` + last
+	}
+	html += `</p>
+        </div>
+
+        <div class="set-filters">`
+	if dexID > 1 {
+		html += `
+            <a href="/pokemon/` + strconv.Itoa(dexID-1) + `" class="button outline">← n°` + strconv.Itoa(dexID-1) + `</a>`
+	}
+	html += `
+            <a href="/pokemon/` + strconv.Itoa(dexID+1) + `" class="button outline">n°` + strconv.Itoa(dexID+1) + ` →</a>
+        </div>`
+
+	for _, name := range names {
+		group := groups[name]
+		html += `
+        <section class="other-versions">
+            <h3>` + escape(group[0].Name) + ` <span class="results-count">` + strconv.Itoa(len(group)) + ` impression(s)</span></h3>
+            <div class="card-grid">`
+		for _, card := range group {
+			full := card.Card()
+			full.Set.Name = sets[card.SetID].Name
+			html += renderCardTile(HydratedCard{ID: full.ID, Card: full}, "")
+		}
+		html += `
+            </div>
+        </section>`
+	}
+
+	writePage(w, title, html, "")
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Périodes proposées pour l'historique des prix, en jours (0 pour tout l'historique).
+var priceRanges = []struct {
+	Key   string
+	Label string
+	Days  int
+}{
+	{"7d", "7 jours", 7},
+	{"30d", "30 jours", 30},
+	{"90d", "90 jours", 90},
+	{"1y", "1 an", 365},
+	{"all", "Tout", 0},
+}
+
+// Libellés des finitions tracées sur le graphique, dans l'ordre des champs de PriceQuote.
+var priceVariantLabels = []string{"normale", "reverse", "holo"}
+
+// trackedCardIDs renvoie les cartes dont on relève les prix : collection, listes,
+// decks, alertes et cartes ayant déjà un historique.
+func trackedCardIDs() []string {
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id != "" {
+			seen[id] = true
+		}
+	}
+
+	if collection, err := loadCollection(); err == nil {
+		for _, item := range collection.Items {
+			add(item.CardID)
+		}
+	}
+	if favorites, err := loadFavorites(); err == nil {
+		for _, list := range favorites.Lists {
+			for _, id := range list.IDs() {
+				add(id)
+			}
+		}
+	}
+	if decks, err := loadDecks(); err == nil {
+		for _, deck := range decks.Decks {
+			for _, id := range deck.IDs() {
+				add(id)
+			}
+		}
+	}
+	if alerts, err := loadAlerts(); err == nil {
+		for _, alert := range alerts.Alerts {
+			add(alert.CardID)
+		}
+	}
+	if prices, err := loadPrices(); err == nil {
+		for id := range prices.Cards {
+			add(id)
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// snapshotPrices relève les prix des cartes suivies puis évalue les alertes.
+// Elle renvoie le nombre de cartes cotées.
+func snapshotPrices(ctx context.Context) (int, error) {
+	// Un relevé fait avec des données en cache fausserait l'historique.
+	if err := requireFreshData(); err != nil {
+		return 0, err
+	}
+
+	ids := trackedCardIDs()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	pricing, err := priceSource.Prices(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordPriceSnapshots(pricing); err != nil {
+		return 0, err
+	}
+
+	prices, err := loadPrices()
+	if err != nil {
+		return len(pricing), err
+	}
+	return len(pricing), evaluateAlerts(ctx, prices, alertNotifier)
+}
+
+// priceSnapshotJob relève les prix au démarrage puis à chaque intervalle.
+func priceSnapshotJob(interval time.Duration) Job {
+	return Job{
+		Name: "relevé des prix",
+		Run: func(ctx context.Context) {
+			for {
+				count, err := snapshotPrices(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Erreur lors du relevé des prix", "err", err)
+				} else {
+					slog.InfoContext(ctx, "Relevé des prix effectué", "cards", count)
+				}
+				if !sleepContext(ctx, interval) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// priceHistoryHandler gère /card/{id}/prices : historique des prix sur une période et alertes.
+func priceHistoryHandler(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	card, err := getCard(ctx, id)
+	if err != nil {
+		showError(w, "Impossible de récupérer les détails de la carte", err)
+		return
+	}
+
+	prices, err := loadPrices()
+	if err != nil {
+		showError(w, "Impossible de charger l'historique des prix", err)
+		return
+	}
+	alerts, err := loadAlerts()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Erreur lors du chargement des alertes", "err", err)
+	}
+
+	currency := requestCurrency(r)
+	current := priceRanges[1]
+	for _, pr := range priceRanges {
+		if pr.Key == r.FormValue("range") {
+			current = pr
+		}
+	}
+
+	history := prices.History(card.ID)
+	if current.Days > 0 {
+		since := time.Now().AddDate(0, 0, -current.Days).Format(priceDateLayout)
+		i := sort.Search(len(history), func(i int) bool { return history[i].Date >= since })
+		history = history[i:]
+	}
+
+	html := `
+        <div class="page-header">
+            <h2>Historique des prix — <a href="/card/` + escape(card.ID) + `">` + escape(card.Name) + `</a></h2>
+            <p class="results-count">` + fmt.Sprint(len(history)) + ` relevé(s) sur ` + current.Label + `, en ` + currency + `</p>
+        </div>
+
+        <div class="set-filters">`
+	for _, pr := range priceRanges {
+		if pr.Key == current.Key {
+			html += `
+            <span class="button secondary">` + pr.Label + `</span>`
+		} else {
+			html += `
+            <a href="/card/` + escape(card.ID) + `/prices?range=` + pr.Key + `&currency=` + currency + `" class="button outline">` + pr.Label + `</a>`
+		}
+	}
+	for _, c := range priceCurrencies {
+		if c != currency {
+			html += `
+            <a href="/card/` + escape(card.ID) + `/prices?range=` + current.Key + `&currency=` + c + `" class="button outline">En ` + c + `</a>`
+		}
+	}
+	html += `
+        </div>
+
+        <section class="analytics-section">` + lineChartSVG("Prix en "+currency, priceSeries(history, currency), func(x float64) string {
+		return time.Unix(int64(x)*86400, 0).UTC().Format("02/01/06")
+	}) + `
+        </section>
+
+        <section class="analytics-section">
+            <h3>Alertes</h3>`
+
+	if cardAlerts := alerts.ForCard(card.ID); len(cardAlerts) > 0 {
+		html += renderAlertTable(cardAlerts, "/card/"+card.ID+"/prices", false)
+	} else {
+		html += `
+            <p>Aucune alerte sur cette carte.</p>`
+	}
+	html += renderAlertForm(card, currency) + `
+        </section>`
+
+	writePage(w, "Prix "+card.Name, html, "")
+}
+
+// priceSeries construit une série par source et par finition, convertie dans la devise demandée.
+// L'abscisse est le nombre de jours depuis l'époque Unix.
+func priceSeries(history []PriceSnapshot, currency string) []ChartSeries {
+	index := make(map[string]int)
+	var series []ChartSeries
+
+	for _, snapshot := range history {
+		date, err := time.Parse(priceDateLayout, snapshot.Date)
+		if err != nil {
+			continue
+		}
+		day := float64(date.Unix() / 86400)
+
+		for _, q := range snapshot.Quotes {
+			values := []float64{q.Normal, q.Reverse, q.Holo}
+			for i, label := range priceVariantLabels {
+				// Cardmarket ne distingue pas reverse et holo : une seule courbe suffit.
+				if values[i] <= 0 || (i == 2 && values[2] == values[1]) {
+					continue
+				}
+				price, ok := convertPrice(values[i], q.Currency, currency)
+				if !ok {
+					continue
+				}
+
+				name := q.Source + " " + label
+				j, found := index[name]
+				if !found {
+					j = len(series)
+					index[name] = j
+					series = append(series, ChartSeries{Name: name})
+				}
+				series[j].Points = append(series[j].Points, ChartXY{X: day, Y: price})
+			}
+		}
+	}
+
+	return series
+}
@@ -0,0 +1,723 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version actuelle du format de data/prices.json.
+const pricesSchemaVersion = 1
+
+// Fichier d'historique des prix, dans le dossier de données.
+const pricesFile = "prices.json"
+
+// Format des dates des relevés de prix, un relevé par carte et par jour.
+const priceDateLayout = "2006-01-02"
+
+var pricesMu sync.Mutex
+
+// Devises reconnues pour l'affichage des valeurs.
+var priceCurrencies = []string{"EUR", "USD"}
+
+// Pricing est le bloc de prix renvoyé par TCGdex pour une carte.
+type Pricing struct {
+	Cardmarket *CardmarketPricing `json:"cardmarket,omitempty"`
+	TCGPlayer  *TCGPlayerPricing  `json:"tcgplayer,omitempty"`
+}
+
+// CardmarketPricing contient les prix Cardmarket, en euros.
+type CardmarketPricing struct {
+	Updated   string  `json:"updated,omitempty"`
+	Unit      string  `json:"unit,omitempty"`
+	Avg       float64 `json:"avg,omitempty"`
+	Low       float64 `json:"low,omitempty"`
+	Trend     float64 `json:"trend,omitempty"`
+	Avg1      float64 `json:"avg1,omitempty"`
+	Avg7      float64 `json:"avg7,omitempty"`
+	Avg30     float64 `json:"avg30,omitempty"`
+	AvgHolo   float64 `json:"avg-holo,omitempty"`
+	LowHolo   float64 `json:"low-holo,omitempty"`
+	TrendHolo float64 `json:"trend-holo,omitempty"`
+	Avg30Holo float64 `json:"avg30-holo,omitempty"`
+}
+
+// TCGPlayerPricing contient les prix TCGplayer par finition, en dollars.
+type TCGPlayerPricing struct {
+	Updated  string                 `json:"updated,omitempty"`
+	Unit     string                 `json:"unit,omitempty"`
+	Normal   *TCGPlayerVariantPrice `json:"normal,omitempty"`
+	Reverse  *TCGPlayerVariantPrice `json:"reverse-holofoil,omitempty"`
+	Holofoil *TCGPlayerVariantPrice `json:"holofoil,omitempty"`
+}
+
+// TCGPlayerVariantPrice contient les prix TCGplayer d'une finition.
+type TCGPlayerVariantPrice struct {
+	LowPrice    float64 `json:"lowPrice,omitempty"`
+	MidPrice    float64 `json:"midPrice,omitempty"`
+	HighPrice   float64 `json:"highPrice,omitempty"`
+	MarketPrice float64 `json:"marketPrice,omitempty"`
+}
+
+func (p *TCGPlayerVariantPrice) value() float64 {
+	if p == nil {
+		return 0
+	}
+	if p.MarketPrice > 0 {
+		return p.MarketPrice
+	}
+	return p.MidPrice
+}
+
+func firstPositive(values ...float64) float64 {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// PriceQuote est un prix par finition dans une devise, extrait d'un bloc de prix.
+type PriceQuote struct {
+	Source   string  `json:"source"`
+	Currency string  `json:"currency"`
+	Normal   float64 `json:"normal,omitempty"`
+	Reverse  float64 `json:"reverse,omitempty"`
+	Holo     float64 `json:"holo,omitempty"`
+}
+
+// ForVariant renvoie le prix de la finition demandée, ou à défaut celui d'une autre finition.
+func (q PriceQuote) ForVariant(variant string) float64 {
+	switch variant {
+	case "reverse":
+		return firstPositive(q.Reverse, q.Holo, q.Normal)
+	case "holo":
+		return firstPositive(q.Holo, q.Reverse, q.Normal)
+	default:
+		return firstPositive(q.Normal, q.Holo, q.Reverse)
+	}
+}
+
+// Quotes convertit le bloc de prix en un prix par source : tendance Cardmarket
+// en euros et prix du marché TCGplayer en dollars.
+func (p Pricing) Quotes() []PriceQuote {
+	var quotes []PriceQuote
+
+	if cm := p.Cardmarket; cm != nil {
+		holo := firstPositive(cm.TrendHolo, cm.AvgHolo)
+		quote := PriceQuote{
+			Source:   "cardmarket",
+			Currency: currencyOr(cm.Unit, "EUR"),
+			Normal:   firstPositive(cm.Trend, cm.Avg),
+			Reverse:  holo,
+			Holo:     holo,
+		}
+		if quote.Normal > 0 || holo > 0 {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	if tp := p.TCGPlayer; tp != nil {
+		quote := PriceQuote{
+			Source:   "tcgplayer",
+			Currency: currencyOr(tp.Unit, "USD"),
+			Normal:   tp.Normal.value(),
+			Reverse:  tp.Reverse.value(),
+			Holo:     tp.Holofoil.value(),
+		}
+		if quote.Normal > 0 || quote.Reverse > 0 || quote.Holo > 0 {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	return quotes
+}
+
+func currencyOr(unit, fallback string) string {
+	if unit = strings.ToUpper(strings.TrimSpace(unit)); unit != "" {
+		return unit
+	}
+	return fallback
+}
+
+// convertPrice convertit un montant entre euros et dollars au taux configuré (usdPerEUR), qui n'est
+// pas mis à jour automatiquement : un montant converti est approximatif. ok est faux pour une
+// devise inconnue.
+func convertPrice(amount float64, from, to string) (float64, bool) {
+	from, to = currencyOr(from, "EUR"), currencyOr(to, "EUR")
+	switch {
+	case from == to:
+		return amount, true
+	case from == "EUR" && to == "USD":
+		return amount * appConfig.USDPerEUR, true
+	case from == "USD" && to == "EUR":
+		return amount / appConfig.USDPerEUR, true
+	}
+	return 0, false
+}
+
+// priceIn renvoie le prix d'une finition dans la devise demandée, en privilégiant
+// une source qui la cote directement avant de convertir.
+func priceIn(quotes []PriceQuote, variant, currency string) float64 {
+	price, _ := quotePrice(quotes, variant, currency)
+	return price
+}
+
+// quotePrice se comporte comme priceIn et indique en plus si le prix a été converti depuis une
+// autre devise, donc approximatif.
+func quotePrice(quotes []PriceQuote, variant, currency string) (price float64, converted bool) {
+	for _, q := range quotes {
+		if q.Currency == currency {
+			if v := q.ForVariant(variant); v > 0 {
+				return v, false
+			}
+		}
+	}
+	for _, q := range quotes {
+		if v := q.ForVariant(variant); v > 0 {
+			if price, ok := convertPrice(v, q.Currency, currency); ok {
+				return price, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func validCurrency(currency string) bool {
+	for _, c := range priceCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCurrency lit la devise d'affichage (?currency=), en euros par défaut.
+func requestCurrency(r *http.Request) string {
+	if currency := strings.ToUpper(r.FormValue("currency")); validCurrency(currency) {
+		return currency
+	}
+	return "EUR"
+}
+
+func formatMoney(amount float64, currency string) string {
+	symbol := currency
+	switch currency {
+	case "EUR":
+		symbol = "€"
+	case "USD":
+		symbol = "$"
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64) + " " + symbol
+}
+
+// formatApproxMoney affiche un montant précédé de « ≈ » s'il a été converti au taux configuré.
+func formatApproxMoney(amount float64, currency string, approx bool) string {
+	if approx {
+		return `<span class="approx-price" title="Converti au taux configuré de ` + conversionRate() + `">≈ ` + formatMoney(amount, currency) + `</span>`
+	}
+	return formatMoney(amount, currency)
+}
+
+// conversionRate décrit le taux de change utilisé pour les conversions.
+func conversionRate() string {
+	return "1 € = " + strconv.FormatFloat(appConfig.USDPerEUR, 'f', -1, 64) + " $"
+}
+
+// PriceSource fournit les blocs de prix d'un ensemble de cartes.
+type PriceSource interface {
+	Name() string
+	Prices(ctx context.Context, ids []string) (map[string]Pricing, error)
+}
+
+// tcgdexPriceSource lit les prix inclus dans les fiches de cartes TCGdex, via le cache du catalogue.
+type tcgdexPriceSource struct{}
+
+func (tcgdexPriceSource) Name() string { return "TCGdex" }
+
+func (tcgdexPriceSource) Prices(ctx context.Context, ids []string) (map[string]Pricing, error) {
+	prices := make(map[string]Pricing, len(ids))
+	for _, h := range hydrateCards(ctx, ids) {
+		if h.Err == nil && !h.Unknown {
+			prices[h.ID] = h.Card.Pricing
+		}
+	}
+	return prices, nil
+}
+
+// recordedPriceSource relit des blocs de prix enregistrés dans un fichier JSON
+// (objet indexé par ID de carte), pour travailler hors ligne ou avec des prix figés.
+type recordedPriceSource struct {
+	path string
+}
+
+func (s recordedPriceSource) Name() string { return "enregistrement " + s.path }
+
+func (s recordedPriceSource) Prices(ctx context.Context, ids []string) (map[string]Pricing, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded map[string]Pricing
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("enregistrement de prix invalide: %w", err)
+	}
+
+	prices := make(map[string]Pricing, len(ids))
+	for _, id := range ids {
+		if pricing, ok := recorded[id]; ok {
+			prices[id] = pricing
+		}
+	}
+	return prices, nil
+}
+
+// priceSource est la source de prix utilisée par l'application.
+var priceSource PriceSource = tcgdexPriceSource{}
+
+// PriceSnapshot est le relevé quotidien des prix d'une carte.
+type PriceSnapshot struct {
+	Date   string       `json:"date"`
+	Quotes []PriceQuote `json:"quotes"`
+}
+
+// Prices est le contenu de data/prices.json : l'historique des relevés par ID de carte.
+type Prices struct {
+	Version int                        `json:"version"`
+	Cards   map[string][]PriceSnapshot `json:"cards"`
+}
+
+func newPrices() Prices {
+	return Prices{Version: pricesSchemaVersion, Cards: map[string][]PriceSnapshot{}}
+}
+
+// History renvoie les relevés d'une carte, du plus ancien au plus récent.
+func (p Prices) History(cardID string) []PriceSnapshot {
+	return p.Cards[cardID]
+}
+
+// Record enregistre le relevé du jour d'une carte, en remplaçant celui déjà pris le même jour.
+func (p *Prices) Record(cardID string, snapshot PriceSnapshot) {
+	history := p.Cards[cardID]
+	if n := len(history); n > 0 && history[n-1].Date == snapshot.Date {
+		history[n-1] = snapshot
+	} else {
+		history = append(history, snapshot)
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+	}
+	p.Cards[cardID] = history
+}
+
+func loadPrices() (Prices, error) {
+	prices := newPrices()
+
+	data, err := os.ReadFile(dataPath(pricesFile))
+	if os.IsNotExist(err) {
+		return prices, nil
+	}
+	if err != nil {
+		return prices, err
+	}
+
+	if len(data) == 0 {
+		return prices, nil
+	}
+
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return newPrices(), fmt.Errorf("fichier de prix invalide: %w", err)
+	}
+	if prices.Cards == nil {
+		prices.Cards = map[string][]PriceSnapshot{}
+	}
+
+	return prices, nil
+}
+
+func savePrices(prices Prices) error {
+
+	os.MkdirAll(appConfig.DataDir, 0755)
+
+	prices.Version = pricesSchemaVersion
+	data, err := json.Marshal(prices)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataPath(pricesFile), data, 0644)
+}
+
+// updatePrices charge l'historique, applique la modification puis l'enregistre, sous verrou.
+func updatePrices(apply func(*Prices) error) error {
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+
+	prices, err := loadPrices()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(&prices); err != nil {
+		return err
+	}
+
+	return savePrices(prices)
+}
+
+// recordPriceSnapshots enregistre le relevé du jour des cartes cotées. Le fichier n'est pas réécrit
+// si le relevé du jour de chaque carte est déjà enregistré à l'identique.
+func recordPriceSnapshots(pricing map[string]Pricing) error {
+	today := time.Now().Format(priceDateLayout)
+	if prices, err := loadPrices(); err == nil && pricesRecorded(prices, pricing, today) {
+		return nil
+	}
+	return updatePrices(func(p *Prices) error {
+		for id, block := range pricing {
+			if quotes := block.Quotes(); len(quotes) > 0 {
+				p.Record(id, PriceSnapshot{Date: today, Quotes: quotes})
+			}
+		}
+		return nil
+	})
+}
+
+// pricesRecorded indique si le relevé du jour de chaque carte cotée est déjà dans prices.
+func pricesRecorded(prices Prices, pricing map[string]Pricing, today string) bool {
+	for id, block := range pricing {
+		quotes := block.Quotes()
+		if len(quotes) == 0 {
+			continue
+		}
+		history := prices.History(id)
+		if n := len(history); n == 0 || history[n-1].Date != today || !reflect.DeepEqual(history[n-1].Quotes, quotes) {
+			return false
+		}
+	}
+	return true
+}
+
+// PriceTrend compare le prix actuel à un prix de référence plus ancien.
+type PriceTrend struct {
+	Since   string
+	From    float64
+	To      float64
+	Percent float64
+}
+
+// priceTrend calcule l'évolution sur la période à partir de l'historique local,
+// ou à défaut de la moyenne à 30 jours de Cardmarket.
+func priceTrend(history []PriceSnapshot, pricing Pricing, currency string, days int) (PriceTrend, bool) {
+	current := priceIn(pricing.Quotes(), "normal", currency)
+	if current <= 0 {
+		return PriceTrend{}, false
+	}
+
+	limit := time.Now().AddDate(0, 0, -days).Format(priceDateLayout)
+	for _, snapshot := range history {
+		if snapshot.Date > limit {
+			break
+		}
+		if past := priceIn(snapshot.Quotes, "normal", currency); past > 0 {
+			return PriceTrend{Since: snapshot.Date, From: past, To: current, Percent: (current - past) / past * 100}, true
+		}
+	}
+
+	if cm := pricing.Cardmarket; cm != nil && cm.Avg30 > 0 {
+		if past, ok := convertPrice(cm.Avg30, currencyOr(cm.Unit, "EUR"), currency); ok {
+			return PriceTrend{Since: "moyenne Cardmarket à 30 jours", From: past, To: current, Percent: (current - past) / past * 100}, true
+		}
+	}
+
+	return PriceTrend{}, false
+}
+
+func trendClass(percent float64) string {
+	switch {
+	case percent > 0.5:
+		return "trend-up"
+	case percent < -0.5:
+		return "trend-down"
+	}
+	return "trend-flat"
+}
+
+// renderPriceSection affiche la valeur actuelle de la carte et son évolution.
+func renderPriceSection(card Card, history []PriceSnapshot, currency string) string {
+	quotes := card.Pricing.Quotes()
+	if len(quotes) == 0 {
+		return `
+                <div class="price-section">
+                    <h3>Prix</h3>
+                    <p>Aucun prix disponible pour cette carte.</p>
+                    <a href="/card/` + escape(card.ID) + `/prices" class="button outline">Historique et alertes</a>
+                </div>`
+	}
+
+	estimated, converted := quotePrice(quotes, defaultVariant(card), currency)
+	html := `
+                <div class="price-section">
+                    <h3>Prix</h3>
+                    <table class="price-table">
+                        <tr><th>Source</th><th>Normale</th><th>Reverse</th><th>Holo</th></tr>`
+	for _, q := range quotes {
+		html += `
+                        <tr><td>` + escape(q.Source) + `</td>`
+		for _, v := range []float64{q.Normal, q.Reverse, q.Holo} {
+			if v > 0 {
+				html += `<td>` + formatMoney(v, q.Currency) + `</td>`
+			} else {
+				html += `<td>—</td>`
+			}
+		}
+		html += `</tr>`
+	}
+	html += `
+                    </table>
+                    <p class="price-value">Valeur estimée : <strong>` + formatApproxMoney(estimated, currency, converted) + `</strong>`
+	for _, c := range priceCurrencies {
+		if c != currency {
+			html += ` <a href="/card/` + escape(card.ID) + `?currency=` + c + `">en ` + c + `</a>`
+		}
+	}
+	html += `</p>`
+
+	if trend, ok := priceTrend(history, card.Pricing, currency, 30); ok {
+		html += `
+                    <p class="price-trend ` + trendClass(trend.Percent) + `">Tendance : ` + strconv.FormatFloat(trend.Percent, 'f', 1, 64) + ` % depuis ` + escape(trend.Since) + ` (` + formatMoney(trend.From, currency) + ` → ` + formatMoney(trend.To, currency) + `)</p>`
+	}
+
+	return html + `
+                    <a href="/card/` + escape(card.ID) + `/prices?currency=` + currency + `" class="button outline">Historique et alertes</a>
+                </div>`
+}
+
+// ValuedItem est une ligne valorisée d'une liste ou de la collection.
+type ValuedItem struct {
+	CardID   string
+	SetID    string
+	Variant  string
+	Quantity int
+	Unit     float64
+	Paid     float64
+	// Approx indique que le prix a été converti depuis une autre devise, PaidApprox que le prix payé l'a été.
+	Approx     bool
+	PaidApprox bool
+	// PaidUnknown indique un prix payé dans une devise non convertible : Paid vaut 0 et la ligne
+	// est exclue du calcul de la plus-value.
+	PaidUnknown bool
+}
+
+// Valuation regroupe la valeur totale et par set d'une liste ou de la collection.
+type Valuation struct {
+	Currency string
+	Total    float64
+	Paid     float64
+	BySet    map[string]float64
+	// ApproxSets indique les sets dont la valeur comprend des prix convertis.
+	ApproxSets map[string]bool
+	Items      []ValuedItem
+	Unpriced   int
+	// PaidApprox indique qu'une partie du prix payé a été convertie depuis une autre devise.
+	PaidApprox bool
+	// Converted compte les cartes dont la valeur a été convertie depuis une autre devise.
+	Converted int
+	// PaidUnknown compte les lots dont le prix payé n'a pas pu être converti, et PaidUnknownValue
+	// leur valeur, retirée de la plus-value.
+	PaidUnknown      int
+	PaidUnknownValue float64
+}
+
+// Gain renvoie la plus-value : valeur des lignes dont le prix payé est connu, moins ce prix.
+func (v Valuation) Gain() float64 {
+	return v.Total - v.PaidUnknownValue - v.Paid
+}
+
+// valueItems valorise les lignes dans la devise demandée à partir des prix de la source.
+func valueItems(items []ValuedItem, pricing map[string]Pricing, currency string) Valuation {
+	valuation := Valuation{Currency: currency, BySet: make(map[string]float64), ApproxSets: make(map[string]bool)}
+
+	for _, item := range items {
+		var converted bool
+		item.Unit, converted = quotePrice(pricing[item.CardID].Quotes(), item.Variant, currency)
+		if item.Unit <= 0 {
+			valuation.Unpriced += item.Quantity
+		}
+		if converted {
+			item.Approx = true
+			valuation.Converted += item.Quantity
+			valuation.ApproxSets[item.SetID] = true
+		}
+		value := item.Unit * float64(item.Quantity)
+		valuation.Total += value
+		valuation.BySet[item.SetID] += value
+		valuation.Paid += item.Paid
+		valuation.PaidApprox = valuation.PaidApprox || item.PaidApprox
+		if item.PaidUnknown {
+			valuation.PaidUnknown++
+			valuation.PaidUnknownValue += value
+		}
+		valuation.Items = append(valuation.Items, item)
+	}
+
+	return valuation
+}
+
+// valuationItems construit les lignes à valoriser : les lots de la collection, ou une liste
+// (un exemplaire de la finition normale par carte).
+func valuationItems(target, currency string) ([]ValuedItem, string, error) {
+	if target == "collection" {
+		collection, err := loadCollection()
+		if err != nil {
+			return nil, "", err
+		}
+		var items []ValuedItem
+		for _, owned := range collection.Items {
+			setID, _ := splitCardID(owned.CardID)
+			if owned.SetID != "" {
+				setID = owned.SetID
+			}
+			item := ValuedItem{CardID: owned.CardID, SetID: setID, Variant: owned.Variant, Quantity: owned.Quantity}
+			if owned.PricePaid > 0 {
+				paid, ok := convertPrice(owned.PricePaid*float64(owned.Quantity), owned.Currency, currency)
+				item.Paid = paid
+				item.PaidApprox = ok && currencyOr(owned.Currency, "EUR") != currency
+				item.PaidUnknown = !ok
+			}
+			items = append(items, item)
+		}
+		return items, "Ma collection", nil
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		return nil, "", err
+	}
+	list := favorites.List(target)
+	if list == nil {
+		return nil, "", errListNotFound
+	}
+	var items []ValuedItem
+	for _, id := range list.IDs() {
+		setID, _ := splitCardID(id)
+		items = append(items, ValuedItem{CardID: id, SetID: setID, Variant: "normal", Quantity: 1})
+	}
+	return items, "Liste « " + list.Name + " »", nil
+}
+
+// valueHandler gère /value?target={collection|slug}&currency={EUR|USD}.
+func valueHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	target := r.FormValue("target")
+	if target == "" {
+		target = "collection"
+	}
+	currency := requestCurrency(r)
+
+	items, label, err := valuationItems(target, currency)
+	if err != nil {
+		showError(w, "Impossible de charger les cartes à valoriser", err)
+		return
+	}
+
+	ids := make([]string, 0, len(items))
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if !seen[item.CardID] {
+			seen[item.CardID] = true
+			ids = append(ids, item.CardID)
+		}
+	}
+
+	pricing, err := priceSource.Prices(ctx, ids)
+	if err != nil {
+		showError(w, "Impossible de récupérer les prix", err)
+		return
+	}
+	valuation := valueItems(items, pricing, currency)
+
+	html := `
+        <div class="page-header">
+            <h2>Valeur — ` + escape(label) + `</h2>
+            <p class="results-count">Prix ` + escape(priceSource.Name()) + `, en ` + currency
+	for _, c := range priceCurrencies {
+		if c != currency {
+			html += ` — <a href="/value?target=` + escape(target) + `&currency=` + c + `">afficher en ` + c + `</a>`
+		}
+	}
+	html += `</p>
+        </div>
+
+        <ul class="analytics-odds">
+            <li>Valeur totale : <strong>` + formatApproxMoney(valuation.Total, currency, valuation.Converted > 0) + `</strong></li>`
+	if valuation.Paid > 0 {
+		html += `
+            <li>Prix payé : <strong>` + formatApproxMoney(valuation.Paid, currency, valuation.PaidApprox) + `</strong> (plus-value ` + formatApproxMoney(valuation.Gain(), currency, valuation.PaidApprox || valuation.Converted > 0) + `)</li>`
+	}
+	if valuation.Unpriced > 0 {
+		html += `
+            <li>` + strconv.Itoa(valuation.Unpriced) + ` carte(s) sans prix connu, comptées pour 0.</li>`
+	}
+	if valuation.PaidUnknown > 0 {
+		html += `
+            <li>` + strconv.Itoa(valuation.PaidUnknown) + ` lot(s) payé(s) dans une devise non convertible en ` + currency + `, exclus du prix payé et de la plus-value.</li>`
+	}
+	if valuation.Converted > 0 {
+		html += `
+            <li>` + strconv.Itoa(valuation.Converted) + ` carte(s) cotée(s) dans une autre devise, converties au taux approximatif de ` + conversionRate() + `.</li>`
+	}
+	html += `
+        </ul>`
+
+	setIDs := make([]string, 0, len(valuation.BySet))
+	for setID := range valuation.BySet {
+		setIDs = append(setIDs, setID)
+	}
+	sort.Slice(setIDs, func(i, j int) bool { return valuation.BySet[setIDs[i]] > valuation.BySet[setIDs[j]] })
+
+	html += `
+        <section class="analytics-section">
+            <h3>Valeur par set</h3>
+            <table class="deck-table">
+                <tr><th>Set</th><th>Valeur</th></tr>`
+	for _, setID := range setIDs {
+		name := setID
+		if set, err := getSet(ctx, setID); err == nil {
+			name = set.Name
+		}
+		html += `
+                <tr><td><a href="/set/` + escape(setID) + `">` + escape(name) + `</a></td><td>` + formatApproxMoney(valuation.BySet[setID], currency, valuation.ApproxSets[setID]) + `</td></tr>`
+	}
+	html += `
+            </table>
+        </section>
+
+        <section class="analytics-section">
+            <h3>Détail</h3>
+            <table class="deck-table">
+                <tr><th>Carte</th><th>Finition</th><th>Quantité</th><th>Prix unitaire</th><th>Valeur</th></tr>`
+	sort.SliceStable(valuation.Items, func(i, j int) bool {
+		return valuation.Items[i].Unit*float64(valuation.Items[i].Quantity) > valuation.Items[j].Unit*float64(valuation.Items[j].Quantity)
+	})
+	for _, item := range valuation.Items {
+		unit := "—"
+		if item.Unit > 0 {
+			unit = formatApproxMoney(item.Unit, currency, item.Approx)
+		}
+		html += `
+                <tr><td><a href="/card/` + escape(item.CardID) + `">` + escape(item.CardID) + `</a></td><td>` + escape(item.Variant) + `</td><td>` + strconv.Itoa(item.Quantity) + `</td><td>` + unit + `</td><td>` + formatApproxMoney(item.Unit*float64(item.Quantity), currency, item.Approx) + `</td></tr>`
+	}
+	html += `
+            </table>
+        </section>`
+
+	writePage(w, "Valeur", html, "")
+}
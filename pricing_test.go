@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// loadRecordedPrices lit les blocs de prix enregistrés dans testdata/pricing.json.
+func loadRecordedPrices(t *testing.T, ids ...string) map[string]Pricing {
+	t.Helper()
+	prices, err := recordedPriceSource{path: "testdata/pricing.json"}.Prices(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("lecture de l'enregistrement: %v", err)
+	}
+	return prices
+}
+
+// withUSDPerEUR fixe le taux de change le temps du test.
+func withUSDPerEUR(t *testing.T, rate float64) {
+	t.Helper()
+	previous := appConfig.USDPerEUR
+	appConfig.USDPerEUR = rate
+	t.Cleanup(func() { appConfig.USDPerEUR = previous })
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRecordedPriceSourceKeepsRequestedIDs(t *testing.T) {
+	prices := loadRecordedPrices(t, "sv03-125", "inconnu-1")
+	if _, ok := prices["sv03-125"]; !ok {
+		t.Error("sv03-125 absent des prix enregistrés")
+	}
+	if _, ok := prices["inconnu-1"]; ok {
+		t.Error("une carte absente de l'enregistrement ne doit pas être renvoyée")
+	}
+	if len(prices) != 1 {
+		t.Errorf("%d blocs renvoyés, 1 attendu", len(prices))
+	}
+}
+
+func TestPricingQuotes(t *testing.T) {
+	prices := loadRecordedPrices(t, "sv03-125", "sv01-001", "swsh1-001", "sv02-002")
+
+	tests := []struct {
+		id   string
+		want []PriceQuote
+	}{
+		{"sv03-125", []PriceQuote{
+			{Source: "cardmarket", Currency: "EUR", Normal: 20},
+			{Source: "tcgplayer", Currency: "USD", Holo: 22.5},
+		}},
+		// Sans tendance, Cardmarket retombe sur la moyenne ; le prix holo sert aussi pour la reverse.
+		{"sv01-001", []PriceQuote{
+			{Source: "cardmarket", Currency: "EUR", Normal: 0.2, Reverse: 1, Holo: 1},
+		}},
+		// TCGplayer préfère le prix du marché au prix médian.
+		{"swsh1-001", []PriceQuote{
+			{Source: "tcgplayer", Currency: "USD", Normal: 0.54, Reverse: 1.08},
+		}},
+		{"sv02-002", nil},
+	}
+	for _, tt := range tests {
+		if got := prices[tt.id].Quotes(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Quotes(%s) = %+v, attendu %+v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPrice(t *testing.T) {
+	withUSDPerEUR(t, 1.25)
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{10, "EUR", "USD", 12.5, true},
+		{12.5, "USD", "EUR", 10, true},
+		{7, "USD", "USD", 7, true},
+		// Une devise vide est considérée comme des euros.
+		{10, "", "USD", 12.5, true},
+		{10, "GBP", "EUR", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := convertPrice(tt.amount, tt.from, tt.to)
+		if ok != tt.ok || !almostEqual(got, tt.want) {
+			t.Errorf("convertPrice(%v, %q, %q) = %v, %v, attendu %v, %v", tt.amount, tt.from, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPriceIn(t *testing.T) {
+	withUSDPerEUR(t, 1.08)
+	prices := loadRecordedPrices(t, "sv03-125", "swsh1-001", "sv02-002")
+
+	tests := []struct {
+		id, variant, currency string
+		want                  float64
+		converted             bool
+	}{
+		// Une source cotant directement la devise est préférée à une conversion.
+		{"sv03-125", "normal", "EUR", 20, false},
+		{"sv03-125", "normal", "USD", 22.5, false},
+		{"sv03-125", "holo", "USD", 22.5, false},
+		{"swsh1-001", "reverse", "USD", 1.08, false},
+		{"swsh1-001", "reverse", "EUR", 1, true},
+		{"swsh1-001", "normal", "EUR", 0.5, true},
+		{"sv02-002", "normal", "EUR", 0, false},
+	}
+	for _, tt := range tests {
+		quotes := prices[tt.id].Quotes()
+		if got := priceIn(quotes, tt.variant, tt.currency); !almostEqual(got, tt.want) {
+			t.Errorf("priceIn(%s, %s, %s) = %v, attendu %v", tt.id, tt.variant, tt.currency, got, tt.want)
+		}
+		if _, converted := quotePrice(quotes, tt.variant, tt.currency); converted != tt.converted {
+			t.Errorf("quotePrice(%s, %s, %s) converti = %v, attendu %v", tt.id, tt.variant, tt.currency, converted, tt.converted)
+		}
+	}
+}
+
+func TestPricesRecord(t *testing.T) {
+	snapshot := func(date string, normal float64) PriceSnapshot {
+		return PriceSnapshot{Date: date, Quotes: []PriceQuote{{Source: "cardmarket", Currency: "EUR", Normal: normal}}}
+	}
+
+	prices := newPrices()
+	prices.Record("sv03-125", snapshot("2024-05-02", 10))
+	prices.Record("sv03-125", snapshot("2024-05-03", 11))
+	// Un second relevé le même jour remplace le premier.
+	prices.Record("sv03-125", snapshot("2024-05-03", 12))
+	// Un relevé plus ancien est rangé à sa place.
+	prices.Record("sv03-125", snapshot("2024-05-01", 9))
+
+	want := []PriceSnapshot{snapshot("2024-05-01", 9), snapshot("2024-05-02", 10), snapshot("2024-05-03", 12)}
+	if got := prices.History("sv03-125"); !reflect.DeepEqual(got, want) {
+		t.Errorf("History = %+v, attendu %+v", got, want)
+	}
+	if got := prices.History("sv01-001"); len(got) != 0 {
+		t.Errorf("History d'une carte sans relevé = %+v", got)
+	}
+}
+
+func TestPriceTrend(t *testing.T) {
+	withUSDPerEUR(t, 1.08)
+	pricing := loadRecordedPrices(t, "sv03-125", "sv02-002")
+	daysAgo := func(days int) string { return time.Now().AddDate(0, 0, -days).Format(priceDateLayout) }
+	history := []PriceSnapshot{
+		{Date: daysAgo(45), Quotes: []PriceQuote{{Source: "cardmarket", Currency: "EUR", Normal: 8}}},
+		{Date: daysAgo(40), Quotes: []PriceQuote{{Source: "cardmarket", Currency: "EUR", Normal: 10}}},
+		{Date: daysAgo(5), Quotes: []PriceQuote{{Source: "cardmarket", Currency: "EUR", Normal: 18}}},
+	}
+
+	// Le plus ancien relevé pris avant le début de la période sert de référence.
+	trend, ok := priceTrend(history, pricing["sv03-125"], "EUR", 30)
+	if !ok || trend.Since != daysAgo(45) || trend.From != 8 || trend.To != 20 || !almostEqual(trend.Percent, 150) {
+		t.Errorf("tendance sur l'historique = %+v, %v", trend, ok)
+	}
+
+	// Sans relevé assez ancien, la moyenne Cardmarket à 30 jours est convertie dans la devise demandée.
+	trend, ok = priceTrend(history[2:], pricing["sv03-125"], "USD", 30)
+	if !ok || !almostEqual(trend.From, 27) || trend.To != 22.5 || !almostEqual(trend.Percent, -100.0/6) {
+		t.Errorf("tendance sur la moyenne à 30 jours = %+v, %v", trend, ok)
+	}
+
+	if trend, ok := priceTrend(history, pricing["sv02-002"], "EUR", 30); ok {
+		t.Errorf("tendance d'une carte sans prix = %+v", trend)
+	}
+}
+
+func TestValueItems(t *testing.T) {
+	withUSDPerEUR(t, 1.08)
+	pricing := loadRecordedPrices(t, "sv03-125", "swsh1-001", "sv02-002")
+	items := []ValuedItem{
+		{CardID: "sv03-125", SetID: "sv03", Variant: "normal", Quantity: 2, Paid: 30},
+		{CardID: "swsh1-001", SetID: "swsh1", Variant: "reverse", Quantity: 3, Paid: 2, PaidApprox: true},
+		{CardID: "sv02-002", SetID: "sv02", Variant: "normal", Quantity: 1},
+	}
+
+	valuation := valueItems(items, pricing, "EUR")
+	if !almostEqual(valuation.Total, 43) || valuation.Paid != 32 {
+		t.Errorf("total = %v, payé = %v, attendu 43 et 32", valuation.Total, valuation.Paid)
+	}
+	wantSets := map[string]float64{"sv03": 40, "swsh1": 3, "sv02": 0}
+	for setID, want := range wantSets {
+		if got := valuation.BySet[setID]; !almostEqual(got, want) {
+			t.Errorf("valeur du set %s = %v, attendu %v", setID, got, want)
+		}
+	}
+	if valuation.Unpriced != 1 {
+		t.Errorf("%d carte(s) sans prix, 1 attendue", valuation.Unpriced)
+	}
+	if valuation.Converted != 3 || !valuation.ApproxSets["swsh1"] || valuation.ApproxSets["sv03"] || !valuation.PaidApprox {
+		t.Errorf("conversions mal signalées: %d converties, sets %v, payé approché %v", valuation.Converted, valuation.ApproxSets, valuation.PaidApprox)
+	}
+	if len(valuation.Items) != 3 || valuation.Items[0].Unit != 20 || valuation.Items[0].Approx || !valuation.Items[1].Approx {
+		t.Errorf("lignes valorisées = %+v", valuation.Items)
+	}
+}
+
+func TestValueItemsExcludesUnconvertiblePaid(t *testing.T) {
+	withUSDPerEUR(t, 1.08)
+	pricing := loadRecordedPrices(t, "sv03-125")
+	items := []ValuedItem{
+		{CardID: "sv03-125", SetID: "sv03", Variant: "normal", Quantity: 1, Paid: 15},
+		// Payé en livres : le prix payé est inconnu en euros.
+		{CardID: "sv03-125", SetID: "sv03", Variant: "normal", Quantity: 2, PaidUnknown: true},
+	}
+
+	valuation := valueItems(items, pricing, "EUR")
+	if valuation.Total != 60 || valuation.Paid != 15 || valuation.PaidUnknown != 1 || valuation.PaidUnknownValue != 40 {
+		t.Errorf("valorisation = %+v", valuation)
+	}
+	// La plus-value ne porte que sur le lot dont le prix payé est connu.
+	if gain := valuation.Gain(); gain != 5 {
+		t.Errorf("plus-value = %v, attendu 5", gain)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitRule autorise Requests requêtes par Per et par client sur les routes commençant par Prefix.
+type RateLimitRule struct {
+	Prefix   string
+	Requests int
+	Per      time.Duration
+}
+
+func (r RateLimitRule) String() string {
+	return fmt.Sprintf("%s=%d/%s", r.Prefix, r.Requests, Duration(r.Per))
+}
+
+// parseRateLimitRule lit une règle de la forme « /search=20/1m ».
+func parseRateLimitRule(value string) (RateLimitRule, error) {
+	prefix, limit, ok := strings.Cut(value, "=")
+	if !ok || !strings.HasPrefix(prefix, "/") {
+		return RateLimitRule{}, fmt.Errorf("règle %q: forme attendue /route=requêtes/durée", value)
+	}
+	count, period, ok := strings.Cut(limit, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("règle %q: forme attendue /route=requêtes/durée", value)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests < 1 {
+		return RateLimitRule{}, fmt.Errorf("règle %q: nombre de requêtes invalide", value)
+	}
+	per, err := time.ParseDuration(period)
+	if err != nil || per <= 0 {
+		return RateLimitRule{}, fmt.Errorf("règle %q: durée invalide", value)
+	}
+	return RateLimitRule{Prefix: prefix, Requests: requests, Per: per}, nil
+}
+
+// parseRateLimitRules lit les règles et les trie du préfixe le plus long au plus court,
+// pour que la règle la plus précise s'applique.
+func parseRateLimitRules(values []string) ([]RateLimitRule, error) {
+	var rules []RateLimitRule
+	for _, value := range values {
+		rule, err := parseRateLimitRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].Prefix) > len(rules[j].Prefix) })
+	return rules, nil
+}
+
+// parseNetworks lit des adresses IP ou des plages CIDR.
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("adresse IP invalide: %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("plage d'adresses invalide: %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func inNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientBucket est le seau à jetons d'un client pour une règle.
+type clientBucket struct {
+	key    string
+	bucket *tokenBucket
+}
+
+// clientKey renvoie l'identifiant de limite d'une adresse. Une adresse IPv6 est ramenée à son
+// préfixe /64, qu'un client obtient en entier et dans lequel il peut changer d'adresse à volonté.
+func clientKey(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String()
+	}
+	return ip.Mask(net.CIDRMask(64, 8*net.IPv6len)).String() + "/64"
+}
+
+// ClientRateLimiter limite les requêtes de chaque adresse IP, route par route, en mémoire.
+// Au plus maxClients seaux sont conservés : les seaux inactifs sont purgés périodiquement et,
+// si la limite est atteinte entre deux purges, le moins récemment utilisé est supprimé.
+type ClientRateLimiter struct {
+	mu         sync.Mutex
+	rules      []RateLimitRule
+	trusted    []*net.IPNet
+	allowlist  []*net.IPNet
+	maxClients int
+	// buckets indexe les éléments de recent, qui range les seaux du plus récemment utilisé
+	// au plus ancien.
+	buckets map[string]*list.Element
+	recent  *list.List
+	limited int64
+	evicted int64
+}
+
+func newClientRateLimiter(rules []RateLimitRule, trusted, allowlist []*net.IPNet, maxClients int) *ClientRateLimiter {
+	return &ClientRateLimiter{
+		rules:      rules,
+		trusted:    trusted,
+		allowlist:  allowlist,
+		maxClients: maxClients,
+		buckets:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// clientIP renvoie l'adresse du client. X-Forwarded-For n'est lu que si la connexion vient d'un
+// proxy de confiance ; il est alors parcouru de droite à gauche jusqu'à la première adresse
+// qui n'est pas un proxy de confiance, les précédentes pouvant être forgées par le client.
+func (l *ClientRateLimiter) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !inNetworks(ip, l.trusted) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !inNetworks(hop, l.trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+func (l *ClientRateLimiter) ruleFor(path string) (RateLimitRule, bool) {
+	for _, rule := range l.rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+// Allow consomme un jeton du client pour la règle et renvoie 0, ou l'attente avant le prochain jeton.
+func (l *ClientRateLimiter) Allow(client string, rule RateLimitRule) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	key := client + " " + rule.Prefix
+	var entry *clientBucket
+	if elem, ok := l.buckets[key]; ok {
+		l.recent.MoveToFront(elem)
+		entry = elem.Value.(*clientBucket)
+	} else {
+		if len(l.buckets) >= l.maxClients {
+			l.evictOldest()
+		}
+		requests := float64(rule.Requests)
+		entry = &clientBucket{key: key, bucket: &tokenBucket{rate: requests / rule.Per.Seconds(), burst: requests, tokens: requests, last: now}}
+		l.buckets[key] = l.recent.PushFront(entry)
+	}
+	entry.bucket.refill(now)
+
+	if wait := entry.bucket.wait(); wait > 0 {
+		l.limited++
+		return wait
+	}
+	entry.bucket.tokens--
+	return 0
+}
+
+func (l *ClientRateLimiter) evictOldest() {
+	oldest := l.recent.Back()
+	if oldest == nil {
+		return
+	}
+	l.remove(oldest)
+	l.evicted++
+}
+
+func (l *ClientRateLimiter) remove(elem *list.Element) {
+	l.recent.Remove(elem)
+	delete(l.buckets, elem.Value.(*clientBucket).key)
+}
+
+// Evict supprime les seaux redevenus pleins : un client qui revient repart de toute façon
+// avec un seau plein. Il renvoie le nombre de seaux supprimés.
+func (l *ClientRateLimiter) Evict() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for elem := l.recent.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*clientBucket)
+		entry.bucket.refill(now)
+		if entry.bucket.tokens >= entry.bucket.burst {
+			l.remove(elem)
+			removed++
+		}
+		elem = next
+	}
+	l.evicted += int64(removed)
+	return removed
+}
+
+// Middleware applique la limite à next. Les clients de la liste d'autorisation et les routes
+// sans règle ne sont pas limités ; les autres reçoivent une 429 avec Retry-After.
+func (l *ClientRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := l.ruleFor(r.URL.Path)
+		ip := l.clientIP(r)
+		if !ok || ip == nil || inNetworks(ip, l.allowlist) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wait := l.Allow(clientKey(ip), rule); wait > 0 {
+			seconds := int(math.Ceil(wait.Seconds()))
+			slog.WarnContext(r.Context(), "Limite de requêtes atteinte", "client", ip.String(), "path", r.URL.Path, "rule", rule.String())
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, fmt.Sprintf("Trop de requêtes, veuillez réessayer dans %d s.", seconds), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// evictionJob purge régulièrement les seaux inactifs.
+func (l *ClientRateLimiter) evictionJob(interval time.Duration) Job {
+	return Job{
+		Name: "purge des limites de requêtes",
+		Run: func(ctx context.Context) {
+			for sleepContext(ctx, interval) {
+				if removed := l.Evict(); removed > 0 {
+					slog.DebugContext(ctx, "Compteurs de clients inactifs purgés", "removed", removed)
+				}
+			}
+		},
+		StopTimeout: time.Second,
+	}
+}
+
+// ClientLimiterStatus résume l'activité de la limite de requêtes entrantes, exposé par /health.
+type ClientLimiterStatus struct {
+	Buckets int   `json:"buckets"`
+	Limited int64 `json:"limited"`
+	Evicted int64 `json:"evicted"`
+}
+
+func (l *ClientRateLimiter) Status() ClientLimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return ClientLimiterStatus{Buckets: len(l.buckets), Limited: l.limited, Evicted: l.evicted}
+}
+
+// clientLimiter limite les requêtes entrantes ; il est recréé au démarrage selon la configuration.
+var clientLimiter = newClientRateLimiter(nil, nil, nil, 1)
+
+// Intervalle de purge des compteurs de clients inactifs.
+const rateLimitEvictionInterval = time.Minute
+
+// clientLimiterFromConfig crée la limite de requêtes entrantes décrite par cfg, déjà validée.
+func clientLimiterFromConfig(cfg Config) *ClientRateLimiter {
+	rules, _ := parseRateLimitRules(cfg.RateLimits)
+	trusted, _ := parseNetworks(cfg.TrustedProxies)
+	allowlist, _ := parseNetworks(cfg.RateLimitAllowlist)
+	return newClientRateLimiter(rules, trusted, allowlist, cfg.RateLimitMaxClients)
+}
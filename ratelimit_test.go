@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mustNetworks lit des adresses ou plages CIDR valides.
+func mustNetworks(t *testing.T, values ...string) []*net.IPNet {
+	t.Helper()
+	networks, err := parseNetworks(values)
+	if err != nil {
+		t.Fatalf("plages invalides: %v", err)
+	}
+	return networks
+}
+
+func TestClientIPForwardedFor(t *testing.T) {
+	limiter := newClientRateLimiter(nil, mustNetworks(t, "10.0.0.0/8", "::1"), nil, 10)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"connexion directe", "203.0.113.7:5000", nil, "203.0.113.7"},
+		// Un client qui ne passe pas par un proxy de confiance ne choisit pas son adresse.
+		{"en-tête ignoré hors proxy", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"un proxy", "10.0.0.2:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		// Les adresses à gauche de la première adresse hors proxy peuvent être forgées.
+		{"adresse forgée", "10.0.0.2:80", []string{"1.2.3.4, 198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"plusieurs en-têtes", "10.0.0.2:80", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"proxy IPv6", "[::1]:80", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"adresse illisible", "10.0.0.2:80", []string{"198.51.100.1, inconnu"}, "10.0.0.2"},
+		{"uniquement des proxys", "10.0.0.2:80", []string{"10.0.0.4, 10.0.0.3"}, "10.0.0.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/search", nil)
+			r.RemoteAddr = tt.remote
+			for _, header := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+			if got := limiter.clientIP(r); got.String() != tt.want {
+				t.Errorf("clientIP = %s, attendu %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientKeyGroupsIPv6By64(t *testing.T) {
+	tests := []struct {
+		ip, want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{"::ffff:203.0.113.7", "203.0.113.7"},
+		{"2001:db8:1:2:aaaa::1", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2:bbbb::2", "2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "2001:db8:1:3::/64"},
+	}
+	for _, tt := range tests {
+		if got := clientKey(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("clientKey(%s) = %s, attendu %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientRateLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	rule := RateLimitRule{Prefix: "/search", Requests: 1, Per: time.Hour}
+	limiter := newClientRateLimiter([]RateLimitRule{rule}, nil, nil, 2)
+
+	limiter.Allow("a", rule)
+	limiter.Allow("b", rule)
+	// « a » redevient le plus récent : « b » est supprimé à l'arrivée de « c ».
+	if wait := limiter.Allow("a", rule); wait == 0 {
+		t.Fatal("la deuxième requête de a devrait être limitée")
+	}
+	limiter.Allow("c", rule)
+
+	if status := limiter.Status(); status.Buckets != 2 || status.Evicted != 1 {
+		t.Errorf("état = %+v, 2 seaux et 1 suppression attendus", status)
+	}
+	if wait := limiter.Allow("a", rule); wait == 0 {
+		t.Error("le seau de a a été supprimé à la place du moins récent")
+	}
+	if wait := limiter.Allow("b", rule); wait != 0 {
+		t.Errorf("b repart avec un seau plein, attente de %s", wait)
+	}
+}
+
+func TestClientRateLimiterEvictRemovesFullBuckets(t *testing.T) {
+	rule := RateLimitRule{Prefix: "/search", Requests: 2, Per: time.Hour}
+	limiter := newClientRateLimiter([]RateLimitRule{rule}, nil, nil, 10)
+
+	limiter.Allow("a", rule)
+	limiter.Allow("b", rule)
+	limiter.buckets["b "+rule.Prefix].Value.(*clientBucket).bucket.tokens = 2
+
+	if removed := limiter.Evict(); removed != 1 {
+		t.Errorf("%d seaux purgés, 1 attendu", removed)
+	}
+	if _, ok := limiter.buckets["a "+rule.Prefix]; !ok || limiter.recent.Len() != 1 {
+		t.Errorf("seul le seau entamé de a doit rester, %d seaux", limiter.recent.Len())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy décrit les nouvelles tentatives d'un appel à l'API : au plus Attempts essais,
+// séparés par une attente qui double à chaque échec à partir de BaseDelay, plafonnée à MaxDelay,
+// dont une part aléatoire (Jitter, entre 0 et 1) évite que les clients ne réessaient en même temps.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Jitter    float64
+}
+
+// Part aléatoire de l'attente entre deux tentatives.
+const retryJitter = 0.5
+
+// retryPolicy renvoie la politique de nouvelles tentatives configurée.
+func retryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  appConfig.Retries,
+		BaseDelay: time.Duration(appConfig.RetryBaseDelay),
+		MaxDelay:  time.Duration(appConfig.RetryMaxDelay),
+		Jitter:    retryJitter,
+	}
+}
+
+// Backoff renvoie l'attente avant la tentative suivante, après attempt échecs (à partir de 1).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		spread := time.Duration(float64(delay) * p.Jitter)
+		delay = delay - spread + time.Duration(rand.Int63n(int64(spread)+1))
+	}
+	return delay
+}
+
+// permanentError marque une erreur qu'une nouvelle tentative ne corrigerait pas.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isRetryable indique si une nouvelle tentative peut réussir après err.
+func isRetryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return statusErr.StatusCode >= 500
+	}
+	return true
+}
+
+// retryAfter renvoie l'attente demandée par l'API dans l'en-tête Retry-After (en secondes ou
+// sous forme de date), ou 0 si elle n'en demande pas.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// withRetry appelle fn jusqu'à ce qu'elle réussisse, qu'elle renvoie une erreur définitive,
+// que les tentatives soient épuisées ou que ctx soit annulé.
+func withRetry(ctx context.Context, policy RetryPolicy, name string, fn func(ctx context.Context) error) error {
+	var lastErr error
+	for attempt := 1; attempt <= policy.Attempts; attempt++ {
+		if attempt > 1 {
+			delay := policy.Backoff(attempt - 1)
+			var statusErr *apiStatusError
+			if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > 0 {
+				if statusErr.RetryAfter > policy.MaxDelay {
+					return fmt.Errorf("%s: l'API demande d'attendre %s, au-delà de %s: %w", name, statusErr.RetryAfter, policy.MaxDelay, lastErr)
+				}
+				if statusErr.RetryAfter > delay {
+					delay = statusErr.RetryAfter
+				}
+			}
+			if !sleepContext(ctx, delay) {
+				return fmt.Errorf("%s abandonnée: %w", name, ctx.Err())
+			}
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s abandonnée: %w", name, ctx.Err())
+		}
+		lastErr = err
+		if !isRetryable(err) {
+			return fmt.Errorf("%s échouée: %w", name, err)
+		}
+		slog.WarnContext(ctx, "Tentative échouée", "operation", name, "attempt", attempt, "attempts", policy.Attempts, "err", err)
+	}
+	return fmt.Errorf("toutes les tentatives de %s ont échoué, dernière erreur: %w", name, lastErr)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedAPI est une fausse API qui joue une réponse par appel, la dernière étant répétée,
+// et retient l'heure de chaque appel.
+type scriptedAPI struct {
+	*httptest.Server
+	mu    sync.Mutex
+	hits  []time.Time
+	steps []func(w http.ResponseWriter)
+}
+
+func newScriptedAPI(t *testing.T, steps ...func(w http.ResponseWriter)) *scriptedAPI {
+	t.Helper()
+	api := &scriptedAPI{steps: steps}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		api.hits = append(api.hits, time.Now())
+		step := api.steps[minInt(len(api.hits), len(api.steps))-1]
+		api.mu.Unlock()
+		step(w)
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (a *scriptedAPI) Hits() []time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]time.Time(nil), a.hits...)
+}
+
+func respond(status int, body string, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+// bodyTracker compte les corps de réponse ouverts et fermés par le client.
+type bodyTracker struct {
+	opened, closed atomic.Int64
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	tracker *bodyTracker
+	once    sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { b.tracker.closed.Add(1) })
+	return b.ReadCloser.Close()
+}
+
+func (t *bodyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.opened.Add(1)
+	resp.Body = &trackedBody{ReadCloser: resp.Body, tracker: t}
+	return resp, nil
+}
+
+// testRetryPolicy attend peu entre les tentatives, sans part aléatoire.
+var testRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// fetchWithRetry appelle l'API comme fetchBody, sans limiteur ni disjoncteur.
+func fetchWithRetry(ctx context.Context, policy RetryPolicy, client *http.Client, url string) ([]byte, error) {
+	var body []byte
+	err := withRetry(ctx, policy, "appel de test", func(ctx context.Context) error {
+		var err error
+		body, err = fetchJSONOnce(ctx, client, url)
+		return err
+	})
+	return body, err
+}
+
+func TestRetryNotFoundIsNotRetried(t *testing.T) {
+	api := newScriptedAPI(t, respond(http.StatusNotFound, `{"error":"not found"}`))
+
+	_, err := fetchWithRetry(context.Background(), testRetryPolicy, http.DefaultClient, api.URL)
+	if !isNotFound(err) {
+		t.Fatalf("erreur = %v, 404 attendue", err)
+	}
+	if hits := len(api.Hits()); hits != 1 {
+		t.Errorf("%d appels pour une 404, 1 attendu", hits)
+	}
+}
+
+func TestRetryTransientErrorsUntilSuccess(t *testing.T) {
+	api := newScriptedAPI(t,
+		respond(http.StatusServiceUnavailable, ""),
+		respond(http.StatusBadGateway, ""),
+		respond(http.StatusOK, `{"id":"sv03-125"}`),
+	)
+
+	body, err := fetchWithRetry(context.Background(), testRetryPolicy, http.DefaultClient, api.URL)
+	if err != nil || string(body) != `{"id":"sv03-125"}` {
+		t.Fatalf("réponse = %q, %v", body, err)
+	}
+	if hits := len(api.Hits()); hits != 3 {
+		t.Errorf("%d appels, 3 attendus", hits)
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  func() string
+		minWait time.Duration
+	}{
+		{"secondes", http.StatusTooManyRequests, func() string { return "1" }, time.Second},
+		// La date n'a qu'une précision à la seconde : l'attente est comprise entre 1 et 2 s.
+		{"date", http.StatusServiceUnavailable, func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newScriptedAPI(t,
+				func(w http.ResponseWriter) { respond(tt.status, "", "Retry-After", tt.header())(w) },
+				respond(http.StatusOK, `{}`),
+			)
+
+			if _, err := fetchWithRetry(context.Background(), testRetryPolicy, http.DefaultClient, api.URL); err != nil {
+				t.Fatalf("erreur inattendue: %v", err)
+			}
+			hits := api.Hits()
+			if len(hits) != 2 {
+				t.Fatalf("%d appels, 2 attendus", len(hits))
+			}
+			if wait := hits[1].Sub(hits[0]); wait < tt.minWait || wait > 2500*time.Millisecond {
+				t.Errorf("attente de %s entre les tentatives, entre %s et 2,5 s attendue", wait, tt.minWait)
+			}
+		})
+	}
+}
+
+func TestRetryAfterBeyondMaxDelayGivesUp(t *testing.T) {
+	api := newScriptedAPI(t, respond(http.StatusTooManyRequests, "", "Retry-After", "60"))
+
+	start := time.Now()
+	_, err := fetchWithRetry(context.Background(), testRetryPolicy, http.DefaultClient, api.URL)
+	var statusErr *apiStatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter != time.Minute {
+		t.Fatalf("erreur = %v, 429 avec Retry-After d'une minute attendue", err)
+	}
+	if hits := len(api.Hits()); hits != 1 {
+		t.Errorf("%d appels, 1 attendu", hits)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("abandon après %s, immédiat attendu", elapsed)
+	}
+}
+
+func TestRetryNonJSONBodyIsPermanent(t *testing.T) {
+	api := newScriptedAPI(t, respond(http.StatusOK, "<html>maintenance</html>", "Content-Type", "text/html"))
+
+	_, err := fetchWithRetry(context.Background(), testRetryPolicy, http.DefaultClient, api.URL)
+	var perm *permanentError
+	if !errors.As(err, &perm) {
+		t.Fatalf("erreur = %v, erreur définitive attendue", err)
+	}
+	if hits := len(api.Hits()); hits != 1 {
+		t.Errorf("%d appels pour une réponse illisible, 1 attendu", hits)
+	}
+}
+
+func TestRetryClosesEveryBody(t *testing.T) {
+	api := newScriptedAPI(t,
+		respond(http.StatusServiceUnavailable, "indisponible"),
+		respond(http.StatusTooManyRequests, "trop de requêtes", "Retry-After", "0"),
+		respond(http.StatusOK, "pas du JSON"),
+	)
+	tracker := &bodyTracker{}
+	client := &http.Client{Transport: tracker}
+
+	if _, err := fetchWithRetry(context.Background(), testRetryPolicy, client, api.URL); err == nil {
+		t.Fatal("erreur attendue pour une réponse illisible")
+	}
+	if opened, closed := tracker.opened.Load(), tracker.closed.Load(); opened != 3 || closed != opened {
+		t.Errorf("%d corps ouverts, %d fermés, 3 attendus", opened, closed)
+	}
+}
+
+func TestBackoffCapAndJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, nominal := range want {
+		if got := policy.Backoff(i + 1); got != nominal {
+			t.Errorf("Backoff(%d) sans part aléatoire = %s, attendu %s", i+1, got, nominal)
+		}
+	}
+	// Un grand nombre d'échecs ne dépasse pas le plafond.
+	if got := policy.Backoff(100); got != time.Second {
+		t.Errorf("Backoff(100) = %s, plafond de %s attendu", got, time.Second)
+	}
+
+	policy.Jitter = 0.5
+	for i, nominal := range want {
+		for n := 0; n < 200; n++ {
+			if got := policy.Backoff(i + 1); got < nominal/2 || got > nominal {
+				t.Fatalf("Backoff(%d) = %s, hors de [%s, %s]", i+1, got, nominal/2, nominal)
+			}
+		}
+	}
+}
+
+func TestRetryStopsWhenCancelledDuringBackoff(t *testing.T) {
+	api := newScriptedAPI(t, respond(http.StatusServiceUnavailable, ""))
+	policy := RetryPolicy{Attempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 10 * time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := fetchWithRetry(ctx, policy, http.DefaultClient, api.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("erreur = %v, annulation attendue", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("arrêt après %s, immédiat attendu", elapsed)
+	}
+	if hits := len(api.Hits()); hits != 1 {
+		t.Errorf("%d appels, 1 attendu", hits)
+	}
+}
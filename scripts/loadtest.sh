@@ -0,0 +1,58 @@
+#!/bin/sh
+# Envoie des requêtes simultanées à une instance de Poké Tracker et relève le nombre d'appels
+# à l'API TCGdex demandés par les handlers et le nombre de requêtes réellement envoyées,
+# d'après les compteurs de /health. La comparaison avec et sans regroupement est mesurée
+# par BenchmarkCoalescing (go test -bench Coalescing).
+#
+# L'instance doit être lancée avec l'adresse du script dans la liste d'autorisation, sans quoi
+# la limite de requêtes entrantes (/=120/1m par défaut) répond 429 et fausse la mesure :
+#   go run . -rate-limit-allowlist 127.0.0.1
+# Le script s'arrête en erreur si une réponse 429 est reçue.
+#
+# Usage : scripts/loadtest.sh [URL de base] [nombre de requêtes] [requêtes simultanées] [chemin...]
+# Exemple : scripts/loadtest.sh http://localhost:8080 200 50 /cards /card/sv03-125
+set -eu
+
+BASE_URL=${1:-http://localhost:8080}
+REQUESTS=${2:-200}
+CONCURRENCY=${3:-50}
+[ $# -gt 3 ] && shift 3 || set -- /cards
+
+counter() {
+	curl -s "$BASE_URL/health" | sed -n "s/.*\"$1\":\([0-9]*\).*/\1/p"
+}
+
+if ! curl -sf -o /dev/null "$BASE_URL/health"; then
+	echo "$BASE_URL/health ne répond pas" >&2
+	exit 1
+fi
+
+codes=$(mktemp)
+trap 'rm -f "$codes"' EXIT
+
+calls_before=$(counter calls)
+fetches_before=$(counter upstreamFetches)
+
+start=$(date +%s)
+i=0
+while [ "$i" -lt "$REQUESTS" ]; do
+	for path in "$@"; do
+		echo "$BASE_URL$path"
+	done
+	i=$((i + 1))
+done | xargs -P "$CONCURRENCY" -n 1 curl -s -o /dev/null -w "%{http_code}\n" | sort | uniq -c | sed 's/^ *\([0-9]*\) \(.*\)/HTTP \2 : \1/' >"$codes"
+end=$(date +%s)
+cat "$codes"
+
+if grep -q "^HTTP 429 " "$codes"; then
+	echo "Des requêtes ont été limitées (429) : relancez l'instance avec -rate-limit-allowlist 127.0.0.1" >&2
+	exit 1
+fi
+
+calls=$(($(counter calls) - calls_before))
+fetches=$(($(counter upstreamFetches) - fetches_before))
+
+echo "Requêtes envoyées à l'application : $((REQUESTS * $#)) en $((end - start)) s"
+echo "Appels à l'API demandés           : $calls"
+echo "Requêtes envoyées à l'API         : $fetches"
+echo "Appels regroupés                  : $((calls - fetches))"
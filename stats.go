@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nombre d'entrées affichées dans les classements (raretés, illustrateurs).
+const statsTopN = 15
+
+// NameCount associe un libellé à un nombre de cartes.
+type NameCount struct {
+	Name  string
+	Count int
+}
+
+// YearStat compte les sets et les cartes sortis une année donnée.
+type YearStat struct {
+	Year  string
+	Sets  int
+	Cards int
+}
+
+// HPStat résume la distribution des PV des Pokémon d'un type.
+type HPStat struct {
+	Type   string
+	Count  int
+	Min    int
+	Max    int
+	Avg    float64
+	Median int
+}
+
+// CatalogueStats regroupe les statistiques calculées sur l'index du catalogue.
+type CatalogueStats struct {
+	UpdatedAt    time.Time
+	Cards        int
+	Sets         int
+	ByType       []NameCount
+	ByRarity     []NameCount
+	ByCategory   []NameCount
+	ByYear       []YearStat
+	Illustrators []NameCount
+	HPByType     []HPStat
+	Standard     int
+	Expanded     int
+	ByMark       []NameCount
+}
+
+// statsCache conserve les statistiques calculées pour une version donnée de l'index.
+var statsCache = struct {
+	sync.Mutex
+	updatedAt time.Time
+	stats     CatalogueStats
+}{}
+
+// catalogueStats renvoie les statistiques de l'index, recalculées uniquement après une reconstruction.
+func catalogueStats(index CatalogueIndex) CatalogueStats {
+	statsCache.Lock()
+	defer statsCache.Unlock()
+
+	if statsCache.updatedAt.Equal(index.UpdatedAt) && statsCache.stats.Cards == len(index.Cards) {
+		return statsCache.stats
+	}
+
+	statsCache.stats = computeCatalogueStats(index)
+	statsCache.updatedAt = index.UpdatedAt
+	return statsCache.stats
+}
+
+func sortedCounts(counts map[string]int) []NameCount {
+	result := make([]NameCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, NameCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func computeCatalogueStats(index CatalogueIndex) CatalogueStats {
+	stats := CatalogueStats{UpdatedAt: index.UpdatedAt, Cards: len(index.Cards), Sets: len(index.Sets)}
+
+	sets := make(map[string]IndexedSet, len(index.Sets))
+	years := make(map[string]*YearStat)
+	for _, set := range index.Sets {
+		sets[set.ID] = set
+		year := set.Year()
+		if year == "" {
+			year = "?"
+		}
+		if years[year] == nil {
+			years[year] = &YearStat{Year: year}
+		}
+		years[year].Sets++
+	}
+
+	types := make(map[string]int)
+	rarities := make(map[string]int)
+	categories := make(map[string]int)
+	marks := make(map[string]int)
+	hps := make(map[string][]int)
+
+	for _, card := range index.Cards {
+		for _, t := range card.Types {
+			types[t]++
+			if card.HP > 0 {
+				hps[t] = append(hps[t], card.HP)
+			}
+		}
+		if card.Rarity != "" {
+			rarities[card.Rarity]++
+		}
+		if card.Category != "" {
+			categories[card.Category]++
+		}
+
+		year := sets[card.SetID].Year()
+		if year == "" {
+			year = "?"
+		}
+		if years[year] == nil {
+			years[year] = &YearStat{Year: year}
+		}
+		years[year].Cards++
+
+		set := sets[card.SetID]
+		full := card.Card()
+		if cardLegalIn(full, "standard", func() Legal { return set.Legal }) {
+			stats.Standard++
+			mark := card.RegulationMark
+			if mark == "" {
+				mark = "sans marque"
+			}
+			marks[mark]++
+		}
+		if cardLegalIn(full, "expanded", func() Legal { return set.Legal }) {
+			stats.Expanded++
+		}
+	}
+
+	stats.ByType = sortedCounts(types)
+	stats.ByRarity = sortedCounts(rarities)
+	stats.ByCategory = sortedCounts(categories)
+	for _, artist := range groupArtists(index) {
+		stats.Illustrators = append(stats.Illustrators, NameCount{Name: artist.Name, Count: len(artist.Cards)})
+	}
+	sort.Slice(stats.Illustrators, func(i, j int) bool {
+		if stats.Illustrators[i].Count != stats.Illustrators[j].Count {
+			return stats.Illustrators[i].Count > stats.Illustrators[j].Count
+		}
+		return stats.Illustrators[i].Name < stats.Illustrators[j].Name
+	})
+	stats.ByMark = sortedCounts(marks)
+	sort.Slice(stats.ByMark, func(i, j int) bool { return stats.ByMark[i].Name < stats.ByMark[j].Name })
+
+	for _, year := range years {
+		stats.ByYear = append(stats.ByYear, *year)
+	}
+	sort.Slice(stats.ByYear, func(i, j int) bool { return stats.ByYear[i].Year < stats.ByYear[j].Year })
+
+	for t, values := range hps {
+		sort.Ints(values)
+		total := 0
+		for _, v := range values {
+			total += v
+		}
+		stats.HPByType = append(stats.HPByType, HPStat{
+			Type:   t,
+			Count:  len(values),
+			Min:    values[0],
+			Max:    values[len(values)-1],
+			Avg:    float64(total) / float64(len(values)),
+			Median: values[len(values)/2],
+		})
+	}
+	sort.Slice(stats.HPByType, func(i, j int) bool { return stats.HPByType[i].Avg > stats.HPByType[j].Avg })
+
+	return stats
+}
+
+func countPoints(counts []NameCount, limit int, colors map[string]string) []ChartPoint {
+	if limit > 0 && len(counts) > limit {
+		counts = counts[:limit]
+	}
+	points := make([]ChartPoint, len(counts))
+	for i, c := range counts {
+		points[i] = ChartPoint{Label: c.Name, Value: float64(c.Count), Color: colors[c.Name]}
+	}
+	return points
+}
+
+// statsHandler gère /stats (tableau de bord) et /stats/refresh (POST, reconstruction de l'index).
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, "/stats"), "/") == "refresh" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+			return
+		}
+		lifecycle.Go("reconstruction de l'index", func(ctx context.Context) {
+			if err := refreshCatalogueIndex(ctx); err != nil {
+				slog.ErrorContext(ctx, "Erreur lors de la reconstruction de l'index du catalogue", "err", err)
+			}
+		})
+		http.Redirect(w, r, "/stats", http.StatusSeeOther)
+		return
+	}
+
+	index := currentCatalogueIndex()
+	refreshing, progress, lastErr := catalogueIndexStatus()
+
+	html := `
+        <div class="page-header">
+            <h2>Statistiques du catalogue</h2>`
+
+	status := ""
+	switch {
+	case refreshing:
+		status = `Reconstruction de l'index en cours : ` + escape(progress)
+	case lastErr != nil:
+		status = `Dernière reconstruction en échec : ` + escape(lastErr.Error())
+	}
+
+	if index.UpdatedAt.IsZero() {
+		html += `
+            <p class="results-count">L'index du catalogue n'a pas encore été construit.</p>
+        </div>`
+		if status != "" {
+			html += `
+        <p class="unknown-card">` + status + `</p>`
+		}
+		html += renderStatsRefreshForm(refreshing)
+		writePage(w, "Statistiques du catalogue", html, "")
+		return
+	}
+
+	stats := catalogueStats(index)
+
+	html += `
+            <p class="results-count">` + strconv.Itoa(stats.Cards) + ` cartes dans ` + strconv.Itoa(stats.Sets) + ` sets — index du ` + stats.UpdatedAt.Format("02/01/2006 15:04") + `</p>
+        </div>`
+	if status != "" {
+		html += `
+        <p class="unknown-card">` + status + `</p>`
+	}
+
+	html += `
+        <section class="analytics-section">
+            <h3>Répartition</h3>
+            <div class="analytics-charts">` +
+		barChartSVG("Cartes par type", countPoints(stats.ByType, 0, energyTypeColors)) +
+		barChartSVG("Cartes par rareté", countPoints(stats.ByRarity, statsTopN, nil)) +
+		barChartSVG("Cartes par catégorie", countPoints(stats.ByCategory, 0, nil)) + `
+            </div>
+        </section>`
+
+	var yearSets, yearCards []ChartPoint
+	for _, year := range stats.ByYear {
+		yearSets = append(yearSets, ChartPoint{Label: year.Year, Value: float64(year.Sets)})
+		yearCards = append(yearCards, ChartPoint{Label: year.Year, Value: float64(year.Cards)})
+	}
+	html += `
+        <section class="analytics-section">
+            <h3>Sorties par année</h3>
+            <div class="analytics-charts">` +
+		barChartSVG("Sets par année", yearSets) +
+		barChartSVG("Cartes par année de sortie du set", yearCards) + `
+            </div>
+        </section>
+
+        <section class="analytics-section">
+            <h3>Illustrateurs les plus prolifiques</h3>` +
+		barChartSVG("Cartes par illustrateur", countPoints(stats.Illustrators, statsTopN, nil)) + `
+            <ol class="stats-ranking">`
+	for i, illustrator := range stats.Illustrators {
+		if i == statsTopN {
+			break
+		}
+		html += `
+                <li><a href="` + artistURL(illustrator.Name) + `">` + escape(illustrator.Name) + `</a> — ` + strconv.Itoa(illustrator.Count) + ` cartes</li>`
+	}
+	html += `
+            </ol>
+            <a href="/artists" class="button outline">Tous les illustrateurs</a>
+        </section>`
+
+	var avgHP []ChartPoint
+	for _, hp := range stats.HPByType {
+		avgHP = append(avgHP, ChartPoint{Label: hp.Type, Value: float64(int(hp.Avg + 0.5)), Color: energyTypeColors[hp.Type]})
+	}
+	html += `
+        <section class="analytics-section">
+            <h3>PV par type</h3>` +
+		barChartSVG("PV moyens par type", avgHP) + `
+            <table class="deck-table">
+                <tr><th>Type</th><th>Pokémon</th><th>Min</th><th>Médiane</th><th>Moyenne</th><th>Max</th></tr>`
+	for _, hp := range stats.HPByType {
+		html += `
+                <tr><td>` + escape(hp.Type) + `</td><td>` + strconv.Itoa(hp.Count) + `</td><td>` + strconv.Itoa(hp.Min) + `</td><td>` + strconv.Itoa(hp.Median) + `</td><td>` + strconv.FormatFloat(hp.Avg, 'f', 1, 64) + `</td><td>` + strconv.Itoa(hp.Max) + `</td></tr>`
+	}
+	html += `
+            </table>
+        </section>
+
+        <section class="analytics-section">
+            <h3>Légalité</h3>
+            <ul class="analytics-odds">
+                <li>Cartes jouables en Standard (marques ` + escape(strings.Join(appConfig.StandardRegulationMarks, ", ")) + `) : <strong>` + strconv.Itoa(stats.Standard) + `</strong> (` + formatPercent(float64(stats.Standard)/float64(maxInt(stats.Cards, 1))) + `)</li>
+                <li>Cartes jouables en Expanded : <strong>` + strconv.Itoa(stats.Expanded) + `</strong> (` + formatPercent(float64(stats.Expanded)/float64(maxInt(stats.Cards, 1))) + `)</li>
+            </ul>` +
+		barChartSVG("Cartes Standard par marque de régulation", countPoints(stats.ByMark, 0, nil)) + `
+        </section>` + renderStatsRefreshForm(refreshing)
+
+	writePage(w, "Statistiques du catalogue", html, "")
+}
+
+func renderStatsRefreshForm(refreshing bool) string {
+	if refreshing {
+		return ""
+	}
+	return fmt.Sprintf(`
+        <form action="/stats/refresh" method="POST" class="list-form">
+            <button type="submit" class="button secondary">Reconstruire l'index</button>
+            <span class="results-count">Reconstruit automatiquement toutes les %d heures.</span>
+        </form>`, int(time.Duration(appConfig.CatalogueIndexTTL).Hours()))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Palette utilisée pour les graphiques, reprise des couleurs de la feuille de style.
+var chartColors = []string{"#e83e35", "#3d7dca", "#ffcb05", "#4caf50", "#ff9800", "#705898", "#2a5899", "#b81c15", "#75a7e6", "#d4a503"}
+
+// Dimensions par défaut des graphiques générés.
+const (
+	chartWidth  = 640
+	chartHeight = 280
+	chartMargin = 40
+)
+
+// ChartPoint est une valeur étiquetée d'un graphique en barres.
+type ChartPoint struct {
+	Label string
+	Value float64
+	Color string
+}
+
+// barChartSVG génère un graphique en barres verticales.
+func barChartSVG(title string, points []ChartPoint) string {
+	if len(points) == 0 {
+		return `<p class="no-results">Aucune donnée pour « ` + escape(title) + ` ».</p>`
+	}
+
+	maxValue := 0.0
+	for _, p := range points {
+		maxValue = math.Max(maxValue, p.Value)
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	slot := plotWidth / float64(len(points))
+	barWidth := slot * 0.7
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight, escape(title))
+	fmt.Fprintf(&b, `<text x="%d" y="20" class="chart-title">%s</text>`, chartMargin, escape(title))
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" class="chart-axis"/>`, chartMargin, chartHeight-chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+
+	for i, p := range points {
+		height := p.Value / maxValue * plotHeight
+		x := float64(chartMargin) + float64(i)*slot + (slot-barWidth)/2
+		y := float64(chartHeight-chartMargin) - height
+		color := p.Color
+		if color == "" {
+			color = chartColors[i%len(chartColors)]
+		}
+
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s : %s</title></rect>`,
+			x, y, barWidth, height, color, escape(p.Label), formatChartValue(p.Value))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" class="chart-value">%s</text>`, x+barWidth/2, y-4, formatChartValue(p.Value))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="chart-label">%s</text>`, x+barWidth/2, chartHeight-chartMargin+16, escape(truncateLabel(p.Label, int(slot/6))))
+	}
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// ChartSeries est une série de valeurs d'un graphique en courbes, X étant une abscisse numérique.
+type ChartSeries struct {
+	Name   string
+	Color  string
+	Points []ChartXY
+}
+
+// ChartXY est un point d'une série.
+type ChartXY struct {
+	X float64
+	Y float64
+}
+
+// lineChartSVG génère un graphique en courbes. xLabel convertit une abscisse en libellé d'axe.
+func lineChartSVG(title string, series []ChartSeries, xLabel func(float64) string) string {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	count := 0
+	for _, s := range series {
+		for _, p := range s.Points {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+			count++
+		}
+	}
+	if count == 0 {
+		return `<p class="no-results">Aucune donnée pour « ` + escape(title) + ` ».</p>`
+	}
+	if maxX == minX {
+		minX, maxX = minX-1, maxX+1
+	}
+	if maxY == minY {
+		minY, maxY = minY*0.9, maxY*1.1+1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	px := func(x float64) float64 { return float64(chartMargin) + (x-minX)/(maxX-minX)*plotWidth }
+	py := func(y float64) float64 { return float64(chartHeight-chartMargin) - (y-minY)/(maxY-minY)*plotHeight }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight, escape(title))
+	fmt.Fprintf(&b, `<text x="%d" y="20" class="chart-title">%s</text>`, chartMargin, escape(title))
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" class="chart-axis"/>`, chartMargin, chartHeight-chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" class="chart-axis"/>`, chartMargin, chartMargin, chartMargin, chartHeight-chartMargin)
+
+	for _, y := range []float64{minY, (minY + maxY) / 2, maxY} {
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" class="chart-tick">%s</text>`, chartMargin-4, py(y)+4, formatChartValue(y))
+	}
+	for _, x := range []float64{minX, (minX + maxX) / 2, maxX} {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="chart-label">%s</text>`, px(x), chartHeight-chartMargin+16, escape(xLabel(x)))
+	}
+
+	for i, s := range series {
+		color := s.Color
+		if color == "" {
+			color = chartColors[i%len(chartColors)]
+		}
+		coords := make([]string, len(s.Points))
+		for j, p := range s.Points {
+			coords[j] = fmt.Sprintf("%.1f,%.1f", px(p.X), py(p.Y))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(coords, " "), color)
+		for _, p := range s.Points {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s : %s</title></circle>`,
+				px(p.X), py(p.Y), color, escape(s.Name), escape(xLabel(p.X)), formatChartValue(p.Y))
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" class="chart-legend" fill="%s">%s</text>`, chartWidth-chartMargin-140, chartMargin+14*i, color, escape(s.Name))
+	}
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+func formatChartValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e9 {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+func truncateLabel(label string, max int) string {
+	runes := []rune(label)
+	if max < 3 {
+		max = 3
+	}
+	if len(runes) <= max {
+		return label
+	}
+	return string(runes[:max-1]) + "…"
+}
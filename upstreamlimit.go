@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// errUpstreamBusy signale qu'un appel à l'API a attendu son tour trop longtemps.
+var errUpstreamBusy = errors.New("trop de requêtes en attente vers l'API TCGdex")
+
+// Priorité d'un appel à l'API : les visiteurs passent avant les tâches de fond.
+type trafficPriority int
+
+const (
+	interactiveTraffic trafficPriority = iota
+	backgroundTraffic
+)
+
+type priorityKey struct{}
+
+// withBackgroundPriority marque les appels à l'API faits sous ctx comme du trafic de fond.
+func withBackgroundPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, backgroundTraffic)
+}
+
+// prioritySource fournit une priorité qui peut changer pendant l'appel, comme celle d'un appel
+// partagé qu'un visiteur rejoint.
+type prioritySource interface {
+	Priority() trafficPriority
+}
+
+// priorityOf renvoie la priorité des appels faits sous ctx ; sans marque, ils sont interactifs.
+func priorityOf(ctx context.Context) trafficPriority {
+	switch p := ctx.Value(priorityKey{}).(type) {
+	case trafficPriority:
+		return p
+	case prioritySource:
+		return p.Priority()
+	}
+	return interactiveTraffic
+}
+
+func (p trafficPriority) String() string {
+	if p == backgroundTraffic {
+		return "background"
+	}
+	return "interactive"
+}
+
+// tokenBucket accorde rate jetons par seconde, jusqu'à burst d'avance.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int) *tokenBucket {
+	return &tokenBucket{rate: float64(rate), burst: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// wait renvoie l'attente avant le prochain jeton.
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// UpstreamLimiter limite le débit des appels à l'API avec un budget par priorité. Un appel sans
+// jeton disponible attend son tour, au plus maxWait ; les appels de fond attendent en plus
+// qu'aucun visiteur ne soit en file.
+type UpstreamLimiter struct {
+	mu                 sync.Mutex
+	buckets            map[trafficPriority]*tokenBucket
+	maxWait            time.Duration
+	interactiveWaiting int
+	waited             map[trafficPriority]int64
+	rejected           map[trafficPriority]int64
+}
+
+func newUpstreamLimiter(interactiveRate, backgroundRate int, maxWait time.Duration) *UpstreamLimiter {
+	return &UpstreamLimiter{
+		buckets: map[trafficPriority]*tokenBucket{
+			interactiveTraffic: newTokenBucket(interactiveRate),
+			backgroundTraffic:  newTokenBucket(backgroundRate),
+		},
+		maxWait:  maxWait,
+		waited:   make(map[trafficPriority]int64),
+		rejected: make(map[trafficPriority]int64),
+	}
+}
+
+// upstreamLimiter est partagé par tous les appels à l'API ; il est recréé au démarrage selon la configuration.
+var upstreamLimiter = newUpstreamLimiter(10, 2, 10*time.Second)
+
+// Intervalle maximum entre deux vérifications d'un appel en attente.
+const limiterPollInterval = 50 * time.Millisecond
+
+// Wait attend un jeton pour un appel de la priorité de ctx. La priorité est relue à chaque
+// vérification : un appel de fond rejoint par un visiteur passe dans la file interactive.
+// Il renvoie une erreur enveloppant errUpstreamBusy si l'attente dépasse maxWait, ou l'erreur
+// de ctx s'il est annulé avant.
+func (l *UpstreamLimiter) Wait(ctx context.Context) error {
+	deadline := time.Now().Add(l.maxWait)
+	queued, interactiveQueued := false, false
+
+	l.mu.Lock()
+	defer func() {
+		if interactiveQueued {
+			l.interactiveWaiting--
+		}
+		l.mu.Unlock()
+	}()
+
+	for {
+		priority := priorityOf(ctx)
+		bucket := l.buckets[priority]
+		now := time.Now()
+		bucket.refill(now)
+
+		delay := bucket.wait()
+		if priority == backgroundTraffic && l.interactiveWaiting > 0 && delay < limiterPollInterval {
+			delay = limiterPollInterval
+		}
+		if delay == 0 {
+			bucket.tokens--
+			return nil
+		}
+
+		if !queued {
+			queued = true
+			l.waited[priority]++
+		}
+		if priority == interactiveTraffic && !interactiveQueued {
+			interactiveQueued = true
+			l.interactiveWaiting++
+		}
+		if now.Add(delay).After(deadline) {
+			l.rejected[priority]++
+			return fmt.Errorf("%w (attente de plus de %s)", errUpstreamBusy, l.maxWait)
+		}
+		if delay > limiterPollInterval {
+			delay = limiterPollInterval
+		}
+
+		l.mu.Unlock()
+		ok := sleepContext(ctx, delay)
+		l.mu.Lock()
+		if !ok {
+			return ctx.Err()
+		}
+	}
+}
+
+// LimiterStatus résume l'activité du limiteur pour une priorité, exposé par /health.
+type LimiterStatus struct {
+	Rate     int   `json:"ratePerSecond"`
+	Waited   int64 `json:"queued"`
+	Rejected int64 `json:"rejected"`
+}
+
+func (l *UpstreamLimiter) Status() map[string]LimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := make(map[string]LimiterStatus)
+	for priority, bucket := range l.buckets {
+		status[priority.String()] = LimiterStatus{Rate: int(bucket.rate), Waited: l.waited[priority], Rejected: l.rejected[priority]}
+	}
+	return status
+}